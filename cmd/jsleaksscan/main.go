@@ -3,110 +3,205 @@ package main
 import (
 	"fmt"
 	"jsleaksscan/internal/config" // 导入配置包
-	"jsleaksscan/internal/rules"  // 导入规则包
-	"jsleaksscan/internal/scan"   // 导入扫描逻辑包
+	"jsleaksscan/internal/i18n"
+	"jsleaksscan/internal/rules" // 导入规则包
+	"jsleaksscan/internal/scan"  // 导入扫描逻辑包
+	"jsleaksscan/internal/utils"
 	"os"
 	"runtime"
+	"strings"
 	"time"
 )
 
 func main() {
 	// 记录开始时间
 	startTime := time.Now()
-	fmt.Printf("JsLeaksScan starting at %s...\n", startTime.Format(time.RFC3339))
-	fmt.Printf("Detected %d CPU cores.\n", runtime.NumCPU())
 
 	// --- 1. 解析命令行参数 ---
 	cfg, err := config.ParseFlags()
 	if err != nil {
-		// ParseFlags 内部已经处理了打印帮助信息和错误信息
+		// ParseFlags 内部已经处理了打印帮助信息，这里输出具体的参数错误
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 
-	// 如果是静默模式，后续很多提示信息将不显示
-	if cfg.Quiet {
-		// 可以考虑重定向标准输出到 /dev/null 或 NUL
-		// 但保留标准错误输出用于显示错误
+	// 仅输出发现模式：发现写入原始标准输出，其余信息 (进度、提示、警告) 全部重定向到空设备
+	// 致命错误仍通过标准错误输出显示
+	if cfg.FindingsOnly {
+		devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		if err != nil {
+			i18n.Fprintf(os.Stderr, "错误: 打开 %s 失败: %v\n", os.DevNull, err)
+			os.Exit(1)
+		}
+		scan.FindingsOutput = os.Stdout
+		os.Stdout = devNull
 	}
 
+	cfg.RunID = utils.NewRunID(startTime)
+	i18n.Printf("JsLeaksScan starting at %s (run %s)...\n", startTime.Format(time.RFC3339), cfg.RunID)
+	i18n.Printf("Detected %d CPU cores.\n", runtime.NumCPU())
+
 	if !cfg.Quiet {
-		fmt.Printf("运行模式: %s\n", cfg.Mode)
-		fmt.Printf("配置文件: %s\n", cfg.ConfigFile)
-		fmt.Printf("输出目录: %s\n", cfg.OutputDir)
+		i18n.Printf("运行模式: %s\n", cfg.Mode)
+		if cfg.DefaultRules {
+			i18n.Println("配置文件: (内置默认规则)")
+		} else {
+			i18n.Printf("配置文件: %s\n", cfg.ConfigFile)
+		}
+		i18n.Printf("输出目录: %s\n", cfg.OutputDir)
 		if cfg.Mode == "localScan" {
-			fmt.Printf("扫描目录: %s\n", cfg.LocalDir)
-			fmt.Printf("并发度 (文件处理): %d\n", cfg.ThreadNum)
+			if cfg.HARInput != "" {
+				i18n.Printf("HAR 文件: %s\n", cfg.HARInput)
+			} else {
+				i18n.Printf("扫描路径: %s\n", cfg.LocalDir)
+			}
+			i18n.Printf("并发度 (文件处理): %d\n", cfg.ThreadNum)
 		} else if cfg.Mode == "urlScan" {
+			if cfg.URLListFile != "" {
+				i18n.Printf("URL 文件: %s\n", cfg.URLListFile)
+			}
 			if cfg.SingleURL != "" {
-				fmt.Printf("扫描 URL: %s\n", cfg.SingleURL)
-			} else {
-				fmt.Printf("URL 文件: %s\n", cfg.URLListFile)
+				i18n.Printf("扫描 URL: %s\n", cfg.SingleURL)
+			}
+			if cfg.BucketURL != "" {
+				i18n.Printf("存储桶: %s\n", cfg.BucketURL)
 			}
-			fmt.Printf("并发度 (URL 请求): %d\n", cfg.ThreadNum)
-			fmt.Printf("请求超时: %d 秒\n", cfg.ScanOptions.Timeout)
+			i18n.Printf("并发度 (URL 请求): %d\n", cfg.ThreadNum)
+			i18n.Printf("请求超时: %d 秒\n", cfg.ScanOptions.Timeout)
 			if cfg.ScanOptions.Proxy != "" {
-				fmt.Printf("使用代理: %s\n", cfg.ScanOptions.Proxy)
+				i18n.Printf("使用代理: %s\n", cfg.ScanOptions.Proxy)
 			}
 			// 可以添加打印其他 URL 扫描选项，如 Header, Method 等，如果 Verbose 开启
 			if cfg.Verbose {
-				fmt.Printf("  请求方法: %s\n", cfg.ScanOptions.Method)
+				i18n.Printf("  请求方法: %s\n", cfg.ScanOptions.Method)
 				if cfg.ScanOptions.Header != "" {
-					fmt.Printf("  自定义 Header: %s\n", cfg.ScanOptions.Header)
+					i18n.Printf("  自定义 Header: %s\n", cfg.ScanOptions.Header)
 				}
 				if cfg.ScanOptions.Cookie != "" {
-					fmt.Printf("  自定义 Cookie: %s\n", cfg.ScanOptions.Cookie)
+					i18n.Printf("  自定义 Cookie: %s\n", cfg.ScanOptions.Cookie)
 				}
 				// ... 其他选项
 			}
 		}
 	}
 
-	// --- 2. 读取并编译规则 ---
+	// --- 2. 下载远程配置和 URL 列表，读取并编译规则 ---
+	if err := scan.FetchRemoteInputs(cfg); err != nil {
+		i18n.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
 	if !cfg.Quiet {
-		fmt.Println("正在加载和编译规则...")
+		i18n.Println("正在加载和编译规则...")
 	}
-	ruleJsonStr, err := config.ReadConfigFile(cfg.ConfigFile)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
-		os.Exit(1)
+	ruleJsonStr := rules.DefaultRulesJSON
+	if cfg.DefaultRules {
+		if !cfg.Quiet {
+			i18n.Printf("提示：未指定配置文件 (-c) 且以下位置都没有配置文件，使用内置的默认规则。可通过 -print-default-rules 导出后自定义。\n  %s\n", strings.Join(config.DefaultConfigPaths(), "\n  "))
+		}
+	} else {
+		// 展开配置文件中的 includes 指令，合并被包含的规则文件
+		merged, err := rules.ResolveIncludes(cfg.ConfigFile, scan.RuleIncludeLoader(cfg))
+		if err != nil {
+			i18n.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		ruleJsonStr = merged.JSON
+		if len(merged.Files) > 1 && !cfg.Quiet {
+			i18n.Printf("规则配置包含 %d 个文件，合并后共 %d 条规则:\n  %s\n", len(merged.Files), merged.Rules, strings.Join(merged.Files, "\n  "))
+			for _, override := range merged.Overrides {
+				i18n.Printf("  规则 '%s' (来自 %s) 被 %s 中的同名规则覆盖\n", override.Rule, override.From, override.By)
+			}
+		}
 	}
 
-	compiledRules, err := rules.CompileRules(ruleJsonStr)
+	compiledRules, err := rules.CompileRules(ruleJsonStr, rules.CompileOptions{Multiline: cfg.Multiline, Strict: cfg.StrictRules})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "错误: 编译规则失败: %v\n", err)
+		i18n.Fprintf(os.Stderr, "错误: 编译规则失败: %v\n", err)
 		os.Exit(1)
 	}
 	if compiledRules == nil || (len(compiledRules.Regex) == 0 && len(compiledRules.Literal) == 0) {
-		fmt.Fprintln(os.Stderr, "错误: 配置文件中没有加载到有效的规则。请检查配置文件内容。")
+		i18n.Fprintln(os.Stderr, "错误: 配置文件中没有加载到有效的规则。请检查配置文件内容。")
 		os.Exit(1)
 	}
+	if len(cfg.Tags) > 0 {
+		removed := compiledRules.FilterByTags(cfg.Tags)
+		if len(compiledRules.Regex) == 0 && len(compiledRules.Literal) == 0 {
+			i18n.Fprintf(os.Stderr, "错误: 没有规则带有指定的标签 (-tags %s)。请检查规则配置中的 \"tags\" 字段。\n", strings.Join(cfg.Tags, ","))
+			os.Exit(1)
+		}
+		if !cfg.Quiet {
+			i18n.Printf("按标签 (-tags %s) 筛选规则: 跳过了 %d 条不带这些标签的规则。\n", strings.Join(cfg.Tags, ","), removed)
+		}
+	}
+	// 分层扫描: 指定 -deep 时将规则划分为快速层和深度层依次运行，否则不启用深度层的规则 (test 模式总是使用全部规则)
+	if cfg.Deep {
+		fast, deep := compiledRules.SplitTiers()
+		if !cfg.Quiet {
+			i18n.Printf("分层扫描 (-deep): 快速层 %d 条规则，深度层 %d 条规则 (带 \"deep\" 标签)。\n", fast, deep)
+		}
+	} else if cfg.Mode != "test" {
+		removed := compiledRules.RemoveDeep()
+		if len(compiledRules.Regex) == 0 && len(compiledRules.Literal) == 0 {
+			i18n.Fprintln(os.Stderr, "错误: 所有规则都带有 \"deep\" 标签，需要使用 -deep 启用。")
+			os.Exit(1)
+		}
+		if removed > 0 && !cfg.Quiet && cfg.Verbose {
+			i18n.Printf("跳过了 %d 条带 \"deep\" 标签的启发式规则，使用 -deep 启用。\n", removed)
+		}
+	}
+	if cfg.SeverityPaths != "" {
+		severityJsonStr, err := config.ReadConfigFile(cfg.SeverityPaths)
+		if err != nil {
+			i18n.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		compiledRules.PathSeverities, err = rules.CompilePathSeverities(severityJsonStr)
+		if err != nil {
+			i18n.Fprintf(os.Stderr, "错误: 解析严重级别路径配置 '%s' 失败: %v\n", cfg.SeverityPaths, err)
+			os.Exit(1)
+		}
+	}
 	if !cfg.Quiet {
-		fmt.Printf("规则加载完成: %d 正则表达式, %d 字面量\n", len(compiledRules.Regex), len(compiledRules.Literal))
+		i18n.Printf("规则加载完成: %d 正则表达式, %d 字面量\n", len(compiledRules.Regex), len(compiledRules.Literal))
+		if len(compiledRules.PathSeverities) > 0 {
+			i18n.Printf("已加载 %d 条按路径调整严重级别的配置 (-severity-paths)。\n", len(compiledRules.PathSeverities))
+		}
+		if cfg.StripComments {
+			i18n.Println("已启用注释移除 (-strip-comments): 源码注释中的内容不会被匹配，使用 -v 查看每个来源移除的注释数量。")
+		}
 	}
 
 	// --- 3. 执行扫描 ---
+	stopProfiling, err := startProfiling(cfg.CPUProfile, cfg.MemProfile)
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
 	var scanErr error
 	switch cfg.Mode {
 	case "localScan":
 		scanErr = scan.ScanLocalDirectory(cfg, compiledRules)
 	case "urlScan":
 		scanErr = scan.ScanURLs(cfg, compiledRules)
+	case "test":
+		scanErr = scan.TestRules(cfg, compiledRules)
 	default:
 		// 此处理论上不会到达，因为 ParseFlags 已经校验过 Mode
-		fmt.Fprintf(os.Stderr, "错误: 未知的扫描模式 '%s'\n", cfg.Mode)
+		i18n.Fprintf(os.Stderr, "错误: 未知的扫描模式 '%s'\n", cfg.Mode)
 		os.Exit(1)
 	}
 
 	// 处理扫描过程中可能发生的错误
 	if scanErr != nil {
-		fmt.Fprintf(os.Stderr, "\n扫描过程中发生错误: %v\n", scanErr)
+		i18n.Fprintf(os.Stderr, "\n扫描过程中发生错误: %v\n", scanErr)
 		// 可以选择在这里退出，或者继续执行后续步骤（如打印总时间）
 		// os.Exit(1)
 	}
+	stopProfiling()
 
 	// --- 4. 结束与总结 ---
 	duration := time.Since(startTime)
-	fmt.Printf("\n所有扫描任务完成。总执行时间: %v\n", duration)
+	i18n.Printf("\n所有扫描任务完成。总执行时间: %v\n", duration)
 
 	// 如果有错误发生，以非零状态退出
 	if scanErr != nil {
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"jsleaksscan/internal/i18n"
+	"os"
+	"os/signal"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"syscall"
+)
+
+// startProfiling 按 -cpuprofile/-memprofile 开始性能分析，返回用于结束分析并写出 pprof 文件的函数 (可重复调用)
+// 扫描被 Ctrl+C (SIGINT) 或 SIGTERM 中断时同样会写出已采集的数据，然后退出程序
+func startProfiling(cpuPath, memPath string) (func(), error) {
+	if cpuPath == "" && memPath == "" {
+		return func() {}, nil
+	}
+
+	var cpuFile *os.File
+	if cpuPath != "" {
+		file, err := os.Create(cpuPath)
+		if err != nil {
+			return nil, fmt.Errorf("创建 CPU profile 文件 '%s' 失败: %w", cpuPath, err)
+		}
+		if err := pprof.StartCPUProfile(file); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("启动 CPU profile 失败: %w", err)
+		}
+		cpuFile = file
+	}
+
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			if cpuFile != nil {
+				pprof.StopCPUProfile()
+				if err := cpuFile.Close(); err != nil {
+					i18n.Fprintf(os.Stderr, "错误: 写入 CPU profile 文件 '%s' 失败: %v\n", cpuPath, err)
+				} else {
+					i18n.Fprintf(os.Stderr, "CPU profile 已写入: %s\n", cpuPath)
+				}
+			}
+			if memPath != "" {
+				if err := writeHeapProfile(memPath); err != nil {
+					i18n.Fprintf(os.Stderr, "错误: %v\n", err)
+				} else {
+					i18n.Fprintf(os.Stderr, "内存 profile 已写入: %s\n", memPath)
+				}
+			}
+		})
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		i18n.Fprintf(os.Stderr, "\n收到信号 %v，写出性能分析数据后退出。\n", sig)
+		stop()
+		os.Exit(130)
+	}()
+	return stop, nil
+}
+
+// writeHeapProfile 在 GC 后写出堆内存 profile，反映当前仍在使用的内存以及自启动以来的分配情况
+func writeHeapProfile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建内存 profile 文件 '%s' 失败: %w", path, err)
+	}
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(file); err != nil {
+		file.Close()
+		return fmt.Errorf("写入内存 profile 文件 '%s' 失败: %w", path, err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadConfigFile(t *testing.T) {
+	const rules = `{"aws_key": {"pattern": "AKIA[0-9A-Z]{16}", "severity": "critical"}}`
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(rules))
+	gz.Close()
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"config.json":    []byte(rules),
+		"config.json.gz": compressed.Bytes(),
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadConfigFile(path)
+		if err != nil {
+			t.Errorf("ReadConfigFile(%s): %v", name, err)
+			continue
+		}
+		if got != rules {
+			t.Errorf("ReadConfigFile(%s) = %q, 期望 %q", name, got, rules)
+		}
+	}
+
+	if _, err := ReadConfigFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("不存在的配置文件应返回错误")
+	}
+}
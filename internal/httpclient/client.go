@@ -1,27 +1,55 @@
 package httpclient
 
 import (
-	"fmt"
+	"crypto/tls"
 	"jsleaksscan/internal/config" // 导入配置包
+	"jsleaksscan/internal/i18n"
+	"net"
 	"net/http"
-	"net/url"
 	"time"
 )
 
+// defaultMaxIdleConnsPerHost 未指定 -max-conns-per-host 时每个主机保留的空闲连接数
+const defaultMaxIdleConnsPerHost = 100
+
 // CreateHTTPClient 根据提供的扫描选项创建和配置 HTTP 客户端
 func CreateHTTPClient(opts config.ScanOptions) (*http.Client, error) {
+	// 连接复用参数：扫描时通常对少数主机发起大量请求，默认的每主机 2 个空闲连接会导致频繁重建 TCP/TLS 连接
+	dialer := &net.Dialer{
+		Timeout:   time.Second * time.Duration(opts.Timeout),
+		KeepAlive: time.Second * time.Duration(opts.KeepAlive), // 负数关闭 TCP keep-alive
+	}
+	maxIdlePerHost := opts.MaxConnsPerHost
+	if maxIdlePerHost <= 0 {
+		maxIdlePerHost = defaultMaxIdleConnsPerHost
+	}
 	transport := &http.Transport{
-		// 可以添加其他 Transport 配置，例如 TLS, KeepAlive 等
+		DialContext:         dialer.DialContext,
+		ForceAttemptHTTP2:   true, // 自定义 DialContext 后需显式启用 HTTP/2
+		DisableKeepAlives:   opts.KeepAlive < 0,
+		MaxConnsPerHost:     opts.MaxConnsPerHost,
+		MaxIdleConnsPerHost: maxIdlePerHost,
+		IdleConnTimeout:     time.Second * time.Duration(opts.IdleTimeout),
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	// 覆盖 TLS SNI (-sni)，按 IP 扫描时以指定的主机名握手和校验证书
+	if opts.SNI != "" {
+		transport.TLSClientConfig = &tls.Config{ServerName: opts.SNI}
 	}
 
-	// 配置代理
+	// 配置代理 (支持逗号分隔的多个代理，轮询使用并自动跳过不可达的代理)
 	if opts.Proxy != "" {
-		proxyURL, err := url.Parse(opts.Proxy)
+		pool, err := newProxyPool(opts.Proxy, dialer)
 		if err != nil {
-			return nil, fmt.Errorf("解析代理 URL '%s' 失败: %w", opts.Proxy, err)
+			return nil, err
+		}
+		if pool.socks {
+			// SOCKS5 代理在建立 TCP 连接时生效，HTTPS 请求的 TLS 握手在代理隧道之上进行
+			transport.DialContext = pool.dialContext
+		} else {
+			transport.Proxy = pool.proxyFunc
 		}
-		transport.Proxy = http.ProxyURL(proxyURL)
-		fmt.Printf("提示：使用代理 %s\n", opts.Proxy) // 提示用户正在使用代理
+		i18n.Printf("提示：使用代理 %s\n", opts.Proxy) // 提示用户正在使用代理
 	}
 
 	client := &http.Client{
@@ -0,0 +1,67 @@
+package httpclient
+
+import (
+	"io"
+	"jsleaksscan/internal/config"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// benchmarkConnectionReuse 用 client 分批并发请求 httptest 服务器，并报告每批请求平均新建的连接数 (conns/op)
+func benchmarkConnectionReuse(b *testing.B, client *http.Client) {
+	var conns atomic.Int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond) // 模拟服务器的处理延迟，使多个请求同时进行
+		io.WriteString(w, "ok")
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	// 每次操作并发发出一批请求 (模拟 -t 个 worker 同时请求同一主机)，批次之间连接处于空闲状态
+	const burst = 16
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var wg sync.WaitGroup
+		for j := 0; j < burst; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := client.Get(server.URL)
+				if err != nil {
+					b.Error(err)
+					return
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}()
+		}
+		wg.Wait()
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+	client.CloseIdleConnections()
+}
+
+func BenchmarkConnectionReuse(b *testing.B) {
+	b.Run("DefaultTransport", func(b *testing.B) {
+		// 默认 Transport 每个主机只保留 2 个空闲连接，并发请求超过 2 个时频繁新建连接
+		benchmarkConnectionReuse(b, &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()})
+	})
+	b.Run("CreateHTTPClient", func(b *testing.B) {
+		client, err := CreateHTTPClient(config.ScanOptions{Timeout: 10, KeepAlive: 30, IdleTimeout: 90})
+		if err != nil {
+			b.Fatal(err)
+		}
+		benchmarkConnectionReuse(b, client)
+	})
+}
@@ -0,0 +1,130 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"jsleaksscan/internal/i18n"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitHubSARIFMaxSize 是 GitHub 代码扫描接受的 gzip 压缩后 SARIF 的大小上限
+const GitHubSARIFMaxSize = 10 * 1024 * 1024
+
+// githubMaxAttempts 和 githubMaxWait 控制遇到限流或服务端错误时的重试: 最多尝试的次数，以及每次等待的上限
+const (
+	githubMaxAttempts = 4
+	githubMaxWait     = 2 * time.Minute
+)
+
+// GitHubUpload 是上传 SARIF 到 GitHub 代码扫描 API 的目标和认证信息
+type GitHubUpload struct {
+	APIURL    string // API 地址，例如 https://api.github.com (GitHub Enterprise Server 为 https://HOST/api/v3)
+	Repo      string // OWNER/REPO
+	Token     string // 具有 security_events 权限的令牌
+	CommitSHA string // 分析对应的提交
+	Ref       string // 分析对应的引用，例如 refs/heads/main
+}
+
+// UploadSARIF 将 SARIF 日志压缩并上传到 GitHub 代码扫描 API (POST /repos/{owner}/{repo}/code-scanning/sarifs)，返回上传 ID。
+// 遇到限流 (403/429，按 Retry-After 或 X-RateLimit-Reset 等待) 或 5xx 错误时重试，其他错误附带 API 返回的说明
+func UploadSARIF(client *http.Client, target GitHubUpload, sarif []byte) (string, error) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write(sarif); err != nil {
+		return "", fmt.Errorf("压缩 SARIF 失败: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return "", fmt.Errorf("压缩 SARIF 失败: %w", err)
+	}
+	if compressed.Len() > GitHubSARIFMaxSize {
+		return "", fmt.Errorf("压缩后的 SARIF (%d 字节) 超过 GitHub 的 %d 字节上限", compressed.Len(), GitHubSARIFMaxSize)
+	}
+	payload, err := json.Marshal(map[string]string{
+		"commit_sha": target.CommitSHA,
+		"ref":        target.Ref,
+		"sarif":      base64.StdEncoding.EncodeToString(compressed.Bytes()),
+		"tool_name":  "JsLeaksScan",
+	})
+	if err != nil {
+		return "", fmt.Errorf("序列化上传请求失败: %w", err)
+	}
+	endpoint := strings.TrimSuffix(target.APIURL, "/") + "/repos/" + target.Repo + "/code-scanning/sarifs"
+
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return "", fmt.Errorf("创建上传请求失败: %w", err)
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("Authorization", "Bearer "+target.Token)
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			if attempt < githubMaxAttempts {
+				wait := time.Duration(1<<attempt) * time.Second
+				i18n.Printf("警告: 上传 SARIF 到 GitHub 失败 (%v)，%v 后重试 (%d/%d)。\n", err, wait, attempt, githubMaxAttempts-1)
+				time.Sleep(wait)
+				continue
+			}
+			return "", fmt.Errorf("上传 SARIF 到 GitHub 失败: %w", err)
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusAccepted || resp.StatusCode == http.StatusOK {
+			var accepted struct {
+				ID string `json:"id"`
+			}
+			_ = json.Unmarshal(body, &accepted)
+			return accepted.ID, nil
+		}
+		if wait, retry := githubRetryAfter(resp, attempt); retry {
+			i18n.Printf("警告: GitHub API 返回状态码 %d，%v 后重试 (%d/%d)。\n", resp.StatusCode, wait, attempt, githubMaxAttempts-1)
+			time.Sleep(wait)
+			continue
+		}
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return "", fmt.Errorf("GitHub API 返回状态码 %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return "", fmt.Errorf("GitHub API 返回状态码 %d", resp.StatusCode)
+	}
+}
+
+// githubRetryAfter 判断响应是否为可以重试的限流或服务端错误，并返回重试前的等待时间 (不超过 githubMaxWait)
+func githubRetryAfter(resp *http.Response, attempt int) (time.Duration, bool) {
+	if attempt >= githubMaxAttempts {
+		return 0, false
+	}
+	backoff := time.Duration(1<<attempt) * time.Second
+	switch {
+	case resp.StatusCode >= 500:
+		return backoff, true
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusForbidden:
+		// 403 只有在是限流 (带有 Retry-After 或剩余额度为 0) 时才重试，权限不足等错误直接返回
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			return min(time.Duration(seconds)*time.Second, githubMaxWait), true
+		}
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+				return min(max(time.Until(time.Unix(reset, 0)), time.Second), githubMaxWait), true
+			}
+			return backoff, true
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return backoff, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,353 @@
+package httpclient
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"jsleaksscan/internal/utils"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// maxHARBodySize 记录响应体时每个响应最多保存的字节数，超出部分被截断
+const maxHARBodySize = 1024 * 1024
+
+// HARRecorder 将经过的 HTTP 请求和响应以 HAR 1.2 格式写入文件，可在浏览器开发者工具或 Burp 中打开
+//
+// 每条记录在响应体关闭时 (或请求失败时) 立即追加写入文件，内存占用与请求数量无关；
+// 文件在 Close 时补全 JSON 结尾，未调用 Close 的文件不是合法的 HAR
+type HARRecorder struct {
+	mu      sync.Mutex
+	file    *os.File
+	w       *bufio.Writer
+	entries int
+	bodies  bool // 是否记录响应体
+	err     error
+}
+
+// NewHARRecorder 创建 HAR 文件，bodies 为 true 时同时记录响应体 (每个最多 1MB)
+func NewHARRecorder(path string, bodies bool) (*HARRecorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("创建 HAR 文件 '%s' 失败: %w", path, err)
+	}
+	r := &HARRecorder{file: file, w: bufio.NewWriter(file), bodies: bodies}
+	r.w.WriteString(`{"log":{"version":"1.2","creator":{"name":"JsLeaksScan","version":"1.0"},"entries":[`)
+	return r, nil
+}
+
+// Wrap 返回记录所有请求的 RoundTripper，next 为 nil 时使用 http.DefaultTransport
+// 重定向的每一跳和 HTTP 回退重试都会作为单独的记录写入
+func (r *HARRecorder) Wrap(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &harTransport{next: next, recorder: r}
+}
+
+// Close 补全 HAR 文件的 JSON 结尾并关闭文件，返回写入过程中遇到的第一个错误
+func (r *HARRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return r.err
+	}
+	r.w.WriteString("]}}\n")
+	if err := r.w.Flush(); err != nil && r.err == nil {
+		r.err = err
+	}
+	if err := r.file.Close(); err != nil && r.err == nil {
+		r.err = err
+	}
+	r.file = nil
+	if r.err != nil {
+		return fmt.Errorf("写入 HAR 文件失败: %w", r.err)
+	}
+	return nil
+}
+
+// add 将一条记录追加写入文件
+func (r *HARRecorder) add(entry harEntry) {
+	data, err := json.Marshal(entry)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil || r.err != nil {
+		return
+	}
+	if err != nil {
+		r.err = err
+		return
+	}
+	if r.entries > 0 {
+		r.w.WriteByte(',')
+	}
+	r.w.WriteByte('\n')
+	if _, err := r.w.Write(data); err != nil {
+		r.err = err
+	}
+	r.entries++
+}
+
+// harTransport 记录请求和响应后交给下一个 RoundTripper
+type harTransport struct {
+	next     http.RoundTripper
+	recorder *HARRecorder
+}
+
+func (t *harTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	started := time.Now()
+	entry := harEntry{
+		StartedDateTime: started.Format(time.RFC3339Nano),
+		Request:         newHARRequest(req),
+		Cache:           struct{}{},
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	wait := time.Since(started)
+	if err != nil {
+		entry.Time = durationMillis(wait)
+		entry.Timings = harTimings{Send: 0, Wait: durationMillis(wait), Receive: 0}
+		entry.Response = harResponse{
+			HTTPVersion: req.Proto, Headers: []harNameValue{}, Cookies: []harNameValue{},
+			Content: harContent{MimeType: "x-unknown"}, HeadersSize: -1, BodySize: -1,
+		}
+		entry.Error = err.Error()
+		t.recorder.add(entry)
+		return nil, err
+	}
+
+	entry.Response = harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  strings.TrimSpace(strings.TrimPrefix(resp.Status, fmt.Sprint(resp.StatusCode))),
+		HTTPVersion: resp.Proto,
+		Headers:     harHeaders(resp.Header),
+		Cookies:     []harNameValue{},
+		Content:     harContent{MimeType: resp.Header.Get("Content-Type")},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+	}
+	// 响应体关闭时才能得到大小和接收耗时，因此记录延迟到 Close 时写入
+	resp.Body = &harBody{
+		ReadCloser: resp.Body,
+		recorder:   t.recorder,
+		entry:      entry,
+		started:    started,
+		wait:       wait,
+		keep:       t.recorder.bodies,
+	}
+	return resp, nil
+}
+
+// harBody 包装响应体，统计读取的字节数 (按需保存内容)，并在关闭时写入记录
+type harBody struct {
+	io.ReadCloser
+	recorder *HARRecorder
+	entry    harEntry
+	started  time.Time
+	wait     time.Duration
+	keep     bool
+	buf      bytes.Buffer
+	size     int
+	once     sync.Once
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += n
+	if b.keep && b.buf.Len() < maxHARBodySize {
+		b.buf.Write(p[:min(n, maxHARBodySize-b.buf.Len())])
+	}
+	return n, err
+}
+
+func (b *harBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		total := time.Since(b.started)
+		b.entry.Time = durationMillis(total)
+		b.entry.Timings = harTimings{Send: 0, Wait: durationMillis(b.wait), Receive: durationMillis(total - b.wait)}
+		b.entry.Response.BodySize = b.size
+		b.entry.Response.Content.Size = b.size
+		if b.keep {
+			body := b.buf.Bytes()
+			if utf8.Valid(body) {
+				b.entry.Response.Content.Text = string(body)
+			} else {
+				b.entry.Response.Content.Text = base64.StdEncoding.EncodeToString(body)
+				b.entry.Response.Content.Encoding = "base64"
+			}
+			if b.size > len(body) {
+				b.entry.Response.Content.Comment = fmt.Sprintf("响应体已截断，只记录了前 %d 字节 (扫描程序读取了 %d 字节)", len(body), b.size)
+			}
+		}
+		b.recorder.add(b.entry)
+	})
+	return err
+}
+
+// HAREntry 是从 HAR 文件中读取的一条请求记录，用于离线扫描抓包数据 (-har-input)
+type HAREntry struct {
+	Method       string
+	URL          string
+	Status       int    // 响应状态码，请求失败或未记录响应时为 0
+	RequestBody  []byte // 请求体 (postData)，表单参数按 name=value&... 拼接
+	ResponseBody []byte // 响应体，base64 编码的内容已解码；导出时未包含响应体则为空
+}
+
+// ReadHAR 读取浏览器开发者工具、Burp、mitmproxy 等导出的 HAR 文件 (可以是 gzip 压缩的)
+func ReadHAR(path string) ([]HAREntry, error) {
+	file, err := utils.OpenInput(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 HAR 文件 '%s' 失败: %w", path, err)
+	}
+	defer file.Close()
+
+	var har struct {
+		Log struct {
+			Entries []harEntry `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.NewDecoder(file).Decode(&har); err != nil {
+		return nil, fmt.Errorf("解析 HAR 文件 '%s' 失败: %w", path, err)
+	}
+
+	entries := make([]HAREntry, 0, len(har.Log.Entries))
+	for i, e := range har.Log.Entries {
+		entry := HAREntry{Method: e.Request.Method, URL: e.Request.URL, Status: e.Response.Status}
+		if data := e.Request.PostData; data != nil {
+			if data.Text != "" {
+				entry.RequestBody = []byte(data.Text)
+			} else if len(data.Params) > 0 {
+				params := make([]string, 0, len(data.Params))
+				for _, param := range data.Params {
+					params = append(params, url.QueryEscape(param.Name)+"="+url.QueryEscape(param.Value))
+				}
+				entry.RequestBody = []byte(strings.Join(params, "&"))
+			}
+		}
+		content := e.Response.Content
+		if content.Encoding == "base64" {
+			body, err := base64.StdEncoding.DecodeString(content.Text)
+			if err != nil {
+				return nil, fmt.Errorf("HAR 文件第 %d 条记录 (%s) 的响应体不是有效的 base64: %w", i+1, e.Request.URL, err)
+			}
+			entry.ResponseBody = body
+		} else {
+			entry.ResponseBody = []byte(content.Text)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// durationMillis 将时长转换为 HAR 使用的毫秒数
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// newHARRequest 从请求构造 HAR 请求记录，请求体只记录 -data 设置的字符串
+func newHARRequest(req *http.Request) harRequest {
+	hr := harRequest{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(req.Header),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			hr.QueryString = append(hr.QueryString, harNameValue{Name: name, Value: value})
+		}
+	}
+	if req.GetBody != nil && req.ContentLength != 0 {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			body.Close()
+			hr.BodySize = len(data)
+			hr.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: string(data)}
+		}
+	}
+	return hr
+}
+
+// harHeaders 将 HTTP 头转换为 HAR 的名称/值列表
+func harHeaders(header http.Header) []harNameValue {
+	headers := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			headers = append(headers, harNameValue{Name: name, Value: value})
+		}
+	}
+	return headers
+}
+
+// 以下类型对应 HAR 1.2 规范中的对象，只包含本程序能提供的字段
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Error           string      `json:"_error,omitempty"` // 自定义字段：请求失败的原因 (连接错误、超时等)
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harPostData struct {
+	MimeType string         `json:"mimeType"`
+	Text     string         `json:"text"`
+	Params   []harNameValue `json:"params,omitempty"` // 其他工具导出的表单请求可能只有 params 而没有 text
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
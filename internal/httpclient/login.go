@@ -0,0 +1,261 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"jsleaksscan/internal/utils"
+	"net/http"
+	"net/http/cookiejar"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxLoginBodySize 登录步骤最多读取的响应体大小
+const maxLoginBodySize = 1024 * 1024
+
+// LoginConfig 描述扫描前执行的登录流程 (-login)：依次发送各步骤的请求，
+// 响应设置的 Cookie 保存在客户端的 Cookie Jar 中，提取出的令牌可用于后续步骤和 Headers
+type LoginConfig struct {
+	Steps []LoginStep `json:"steps"`
+	// Headers 登录完成后附加到所有扫描请求的请求头，值中的 {{name}} 替换为提取出的变量 (例如 "Bearer {{token}}")
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// LoginStep 是登录流程中的一个请求，URL、Body 和 Headers 中的 {{name}} 替换为之前步骤提取出的变量
+type LoginStep struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method,omitempty"` // 默认 GET，设置了 Body 时默认 POST
+	Body    string            `json:"body,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Extract []LoginExtract    `json:"extract,omitempty"`
+}
+
+// LoginExtract 从步骤的响应中提取一个变量
+type LoginExtract struct {
+	Name string `json:"name"`
+	// From 为取值位置: "header" (响应头)、"cookie" (Set-Cookie)、"json" (响应体 JSON 中以 . 分隔的路径，如 data.token)
+	// 或 "regex" (响应体中正则的第一个捕获组，没有捕获组时取整个匹配)
+	From string `json:"from"`
+	Expr string `json:"expr"`
+}
+
+// loginVariable 匹配登录配置中的 {{name}} 变量引用
+var loginVariable = regexp.MustCompile(`\{\{\s*([\w.-]+)\s*\}\}`)
+
+// LoadLoginConfig 读取并校验登录流程配置文件 (JSON)
+func LoadLoginConfig(path string) (*LoginConfig, error) {
+	file, err := utils.OpenInput(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var cfg LoginConfig
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("JSON 解码错误: %w", err)
+	}
+	if len(cfg.Steps) == 0 {
+		return nil, fmt.Errorf("没有定义任何登录步骤 (steps)")
+	}
+	for i, step := range cfg.Steps {
+		if strings.TrimSpace(step.URL) == "" {
+			return nil, fmt.Errorf("第 %d 个步骤的 url 为空", i+1)
+		}
+		for _, extract := range step.Extract {
+			if extract.Name == "" || extract.Expr == "" {
+				return nil, fmt.Errorf("第 %d 个步骤的 extract 缺少 name 或 expr", i+1)
+			}
+			switch extract.From {
+			case "header", "cookie", "json":
+			case "regex":
+				if _, err := regexp.Compile(extract.Expr); err != nil {
+					return nil, fmt.Errorf("第 %d 个步骤的正则 '%s' 编译失败: %w", i+1, extract.Expr, err)
+				}
+			default:
+				return nil, fmt.Errorf("第 %d 个步骤的 from '%s' 无效 (可选: header/cookie/json/regex)", i+1, extract.From)
+			}
+		}
+	}
+	return &cfg, nil
+}
+
+// Login 使用 client 执行登录流程，为 client 启用 Cookie Jar 以保存会话 Cookie，
+// 返回需要附加到后续所有请求的请求头。prepare 不为 nil 时在发送每个登录请求前调用 (例如设置 User-Agent)
+func Login(client *http.Client, cfg *LoginConfig, prepare func(*http.Request)) (http.Header, error) {
+	if client.Jar == nil {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return nil, err
+		}
+		client.Jar = jar
+	}
+
+	vars := make(map[string]string)
+	for i, step := range cfg.Steps {
+		if err := runLoginStep(client, step, vars, prepare); err != nil {
+			return nil, fmt.Errorf("第 %d 个登录步骤 (%s) 失败: %w", i+1, step.URL, err)
+		}
+	}
+
+	headers := make(http.Header)
+	for key, value := range cfg.Headers {
+		expanded, err := expandLoginVariables(value, vars)
+		if err != nil {
+			return nil, fmt.Errorf("请求头 '%s': %w", key, err)
+		}
+		headers.Set(key, expanded)
+	}
+	return headers, nil
+}
+
+// runLoginStep 发送一个登录步骤的请求并提取变量，响应状态码为 4xx/5xx 时视为失败
+func runLoginStep(client *http.Client, step LoginStep, vars map[string]string, prepare func(*http.Request)) error {
+	target, err := expandLoginVariables(step.URL, vars)
+	if err != nil {
+		return err
+	}
+	body, err := expandLoginVariables(step.Body, vars)
+	if err != nil {
+		return err
+	}
+	method := strings.ToUpper(step.Method)
+	if method == "" {
+		method = http.MethodGet
+		if body != "" {
+			method = http.MethodPost
+		}
+	}
+
+	var reqBody io.Reader
+	if body != "" {
+		reqBody = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, target, reqBody)
+	if err != nil {
+		return err
+	}
+	if prepare != nil {
+		prepare(req)
+	}
+	if body != "" && req.Header.Get("Content-Type") == "" {
+		// 未指定时按请求体内容猜测表单或 JSON
+		if trimmed := strings.TrimSpace(body); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+			req.Header.Set("Content-Type", "application/json")
+		} else {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+	for key, value := range step.Headers {
+		expanded, err := expandLoginVariables(value, vars)
+		if err != nil {
+			return fmt.Errorf("请求头 '%s': %w", key, err)
+		}
+		req.Header.Set(key, expanded)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxLoginBodySize))
+	if err != nil {
+		return fmt.Errorf("读取响应体失败: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("返回状态码 %d", resp.StatusCode)
+	}
+
+	for _, extract := range step.Extract {
+		value, err := extractLoginValue(resp, respBody, extract)
+		if err != nil {
+			return fmt.Errorf("提取变量 '%s' 失败: %w", extract.Name, err)
+		}
+		vars[extract.Name] = value
+	}
+	return nil
+}
+
+// extractLoginValue 按 extract 的配置从响应中取值，取不到时返回错误
+func extractLoginValue(resp *http.Response, body []byte, extract LoginExtract) (string, error) {
+	var value string
+	switch extract.From {
+	case "header":
+		value = resp.Header.Get(extract.Expr)
+	case "cookie":
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == extract.Expr {
+				value = cookie.Value
+			}
+		}
+	case "json":
+		var data any
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber() // 保留数字的原始写法，避免大整数变为科学计数法
+		if err := decoder.Decode(&data); err != nil {
+			return "", fmt.Errorf("响应体不是有效的 JSON: %w", err)
+		}
+		value = lookupJSONPath(data, extract.Expr)
+	case "regex":
+		match := regexp.MustCompile(extract.Expr).FindSubmatch(body)
+		if len(match) > 1 {
+			value = string(match[1])
+		} else if len(match) == 1 {
+			value = string(match[0])
+		}
+	}
+	if value == "" {
+		return "", fmt.Errorf("响应中没有找到 %s '%s'", extract.From, extract.Expr)
+	}
+	return value, nil
+}
+
+// lookupJSONPath 按以 . 分隔的路径 (数组元素用下标，如 data.items.0.id) 取 JSON 中的值，
+// 找不到或值为对象/数组时返回空字符串
+func lookupJSONPath(data any, path string) string {
+	for _, key := range strings.Split(path, ".") {
+		switch node := data.(type) {
+		case map[string]any:
+			data = node[key]
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return ""
+			}
+			data = node[index]
+		default:
+			return ""
+		}
+	}
+	switch value := data.(type) {
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool:
+		return strconv.FormatBool(value)
+	default:
+		return ""
+	}
+}
+
+// expandLoginVariables 将 s 中的 {{name}} 替换为变量值，引用未定义的变量时返回错误
+func expandLoginVariables(s string, vars map[string]string) (string, error) {
+	var missing string
+	expanded := loginVariable.ReplaceAllStringFunc(s, func(ref string) string {
+		name := loginVariable.FindStringSubmatch(ref)[1]
+		value, ok := vars[name]
+		if !ok && missing == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("引用了未定义的变量 '%s'", missing)
+	}
+	return expanded, nil
+}
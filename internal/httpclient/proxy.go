@@ -0,0 +1,240 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"jsleaksscan/internal/i18n"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+const (
+	proxyProbeTimeout = 3 * time.Second  // 单次代理连通性探测的超时时间
+	proxyRecheckEvery = 30 * time.Second // 代理状态的重新探测间隔
+)
+
+// proxyState 记录单个代理的健康状态
+type proxyState struct {
+	url       *url.URL
+	dialer    proxy.ContextDialer // 仅 SOCKS5 代理：经由该代理建立连接的拨号器
+	alive     bool
+	checking  bool      // 是否有后台探测正在进行
+	checkedAt time.Time // 最近一次探测完成的时间
+}
+
+// proxyPool 管理一个或多个代理，按轮询方式分配给请求并跳过不可用的代理
+// 每个代理超过 proxyRecheckEvery 未探测时会在后台重新探测，不可用的代理恢复后会重新启用
+//
+// HTTP/HTTPS 代理通过 http.Transport.Proxy (proxyFunc) 使用；SOCKS5 代理通过自定义的
+// http.Transport.DialContext (dialContext) 使用。同一列表中不能混用两类代理
+type proxyPool struct {
+	mu      sync.Mutex
+	proxies []*proxyState
+	next    int
+	socks   bool // 列表中的代理均为 SOCKS5 代理
+}
+
+// isSOCKSScheme 判断代理协议是否为 SOCKS5
+// socks5 在本地解析目标域名，socks5h 由代理服务器解析 (适用于 Tor .onion 地址和仅内网可解析的域名)
+func isSOCKSScheme(scheme string) bool {
+	return scheme == "socks5" || scheme == "socks5h"
+}
+
+// newProxyPool 解析逗号分隔的代理列表，并在启动时探测所有代理的连通性
+// forward 为 SOCKS5 代理连接代理服务器本身时使用的拨号器
+// 所有代理均不可用时返回错误，以便在扫描开始前快速失败
+func newProxyPool(proxyList string, forward *net.Dialer) (*proxyPool, error) {
+	pool := &proxyPool{}
+	for _, raw := range strings.Split(proxyList, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		proxyURL, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("解析代理 URL '%s' 失败: %w", raw, err)
+		}
+		if proxyURL.Host == "" {
+			return nil, fmt.Errorf("代理 URL '%s' 缺少主机地址 (例如: http://127.0.0.1:8080)", raw)
+		}
+		state := &proxyState{url: proxyURL}
+		if isSOCKSScheme(proxyURL.Scheme) {
+			dialer, err := newSOCKSDialer(proxyURL, forward)
+			if err != nil {
+				return nil, err
+			}
+			state.dialer = dialer
+		}
+		if len(pool.proxies) == 0 {
+			pool.socks = state.dialer != nil
+		} else if pool.socks != (state.dialer != nil) {
+			return nil, fmt.Errorf("代理列表中不能混用 SOCKS5 代理和 HTTP 代理 ('%s')", proxyURL.Redacted())
+		}
+		pool.proxies = append(pool.proxies, state)
+	}
+	if len(pool.proxies) == 0 {
+		return nil, fmt.Errorf("未提供有效的代理地址")
+	}
+
+	// 并发探测所有代理
+	var wg sync.WaitGroup
+	errs := make([]error, len(pool.proxies))
+	for i, p := range pool.proxies {
+		wg.Add(1)
+		go func(i int, p *proxyState) {
+			defer wg.Done()
+			errs[i] = probeProxy(p.url)
+			p.alive = errs[i] == nil
+			p.checkedAt = time.Now()
+		}(i, p)
+	}
+	wg.Wait()
+
+	alive := 0
+	for i, p := range pool.proxies {
+		if p.alive {
+			alive++
+		} else if len(pool.proxies) > 1 {
+			i18n.Printf("警告：代理 %s 不可达，已暂时跳过: %v\n", p.url.Redacted(), errs[i])
+		}
+	}
+	if alive == 0 {
+		if len(pool.proxies) == 1 {
+			return nil, fmt.Errorf("代理 %s 不可达，请检查代理是否已启动: %w", pool.proxies[0].url.Redacted(), errs[0])
+		}
+		return nil, fmt.Errorf("全部 %d 个代理均不可达，请检查代理设置", len(pool.proxies))
+	}
+	return pool, nil
+}
+
+// proxyFunc 用作 http.Transport.Proxy，按轮询顺序返回下一个可用代理
+func (pool *proxyPool) proxyFunc(_ *http.Request) (*url.URL, error) {
+	p, err := pool.nextProxy()
+	if err != nil {
+		return nil, err
+	}
+	return p.url, nil
+}
+
+// dialContext 用作 http.Transport.DialContext，按轮询顺序经由下一个可用的 SOCKS5 代理建立连接
+func (pool *proxyPool) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	p, err := pool.nextProxy()
+	if err != nil {
+		return nil, err
+	}
+	if p.url.Scheme == "socks5" {
+		// socks5: 在本地解析目标域名，只把 IP 地址交给代理
+		addr, err = resolveLocally(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p.dialer.DialContext(ctx, network, addr)
+}
+
+// nextProxy 按轮询顺序返回下一个可用代理，并触发到期代理的后台重新探测
+func (pool *proxyPool) nextProxy() (*proxyState, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	now := time.Now()
+	var selected *proxyState
+	for i := 0; i < len(pool.proxies); i++ {
+		p := pool.proxies[(pool.next+i)%len(pool.proxies)]
+		if !p.checking && now.Sub(p.checkedAt) >= proxyRecheckEvery {
+			p.checking = true
+			go pool.recheck(p)
+		}
+		if selected == nil && p.alive {
+			selected = p
+			pool.next = (pool.next + i + 1) % len(pool.proxies)
+		}
+	}
+	if selected == nil {
+		return nil, fmt.Errorf("当前没有可用的代理 (共 %d 个，均探测失败)", len(pool.proxies))
+	}
+	return selected, nil
+}
+
+// recheck 在后台重新探测代理，并在状态变化时提示用户
+func (pool *proxyPool) recheck(p *proxyState) {
+	err := probeProxy(p.url)
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if p.alive && err != nil {
+		i18n.Printf("\n警告：代理 %s 已不可达，暂时跳过: %v\n", p.url.Redacted(), err)
+	} else if !p.alive && err == nil {
+		i18n.Printf("\n提示：代理 %s 已恢复可用\n", p.url.Redacted())
+	}
+	p.alive = err == nil
+	p.checking = false
+	p.checkedAt = time.Now()
+}
+
+// newSOCKSDialer 创建经由 SOCKS5 代理建立连接的拨号器，代理 URL 中的用户名和密码用于认证
+func newSOCKSDialer(proxyURL *url.URL, forward *net.Dialer) (proxy.ContextDialer, error) {
+	var auth *proxy.Auth
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		auth = &proxy.Auth{User: proxyURL.User.Username(), Password: password}
+	}
+	dialer, err := proxy.SOCKS5("tcp", proxyAddress(proxyURL), auth, forward)
+	if err != nil {
+		return nil, fmt.Errorf("创建 SOCKS5 代理 '%s' 失败: %w", proxyURL.Redacted(), err)
+	}
+	contextDialer, ok := dialer.(proxy.ContextDialer)
+	if !ok {
+		return nil, fmt.Errorf("SOCKS5 代理 '%s' 不支持 DialContext", proxyURL.Redacted())
+	}
+	return contextDialer, nil
+}
+
+// resolveLocally 在本地解析 addr 中的域名，返回 IP:port
+func resolveLocally(ctx context.Context, addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	if net.ParseIP(host) != nil {
+		return addr, nil
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return "", err
+	}
+	if len(ips) == 0 {
+		return "", fmt.Errorf("域名 '%s' 没有解析到 IP 地址", host)
+	}
+	return net.JoinHostPort(ips[0].IP.String(), port), nil
+}
+
+// probeProxy 通过建立 TCP 连接快速检查代理是否可达
+func probeProxy(proxyURL *url.URL) error {
+	conn, err := net.DialTimeout("tcp", proxyAddress(proxyURL), proxyProbeTimeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// proxyAddress 返回代理的 host:port，未指定端口时按协议补全默认端口
+func proxyAddress(proxyURL *url.URL) string {
+	if proxyURL.Port() != "" {
+		return proxyURL.Host
+	}
+	port := "80"
+	switch proxyURL.Scheme {
+	case "https":
+		port = "443"
+	case "socks5", "socks5h":
+		port = "1080"
+	}
+	return net.JoinHostPort(proxyURL.Hostname(), port)
+}
@@ -0,0 +1,91 @@
+package httpclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// maxRemoteFileSize 远程配置文件和 URL 列表的大小上限
+const maxRemoteFileSize = 64 * 1024 * 1024
+
+// FetchRemoteFile 下载远程文件 (规则配置、URL 列表等) 并缓存到 cacheDir，返回本地缓存文件的路径。
+// 下载失败 (连接错误或状态码不是 2xx) 时，如果之前缓存过同一地址则返回缓存路径，stale 为 true，err 说明下载失败的原因；
+// 没有缓存时 path 为空。prepare 不为 nil 时在发送前调用 (用于附加认证等请求头)
+func FetchRemoteFile(client *http.Client, rawURL, cacheDir string, prepare func(*http.Request)) (path string, stale bool, err error) {
+	cachePath := filepath.Join(cacheDir, remoteCacheName(rawURL))
+	data, err := fetchRemote(client, rawURL, prepare)
+	if err != nil {
+		if _, statErr := os.Stat(cachePath); statErr == nil {
+			return cachePath, true, err
+		}
+		return "", false, err
+	}
+
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", false, fmt.Errorf("创建缓存目录 '%s' 失败: %w", cacheDir, err)
+	}
+	// 先写入临时文件再重命名，避免中断时留下不完整的缓存
+	tmp, err := os.CreateTemp(cacheDir, ".download-*")
+	if err != nil {
+		return "", false, fmt.Errorf("写入缓存失败: %w", err)
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr == nil {
+		writeErr = os.Rename(tmp.Name(), cachePath)
+	}
+	if writeErr != nil {
+		os.Remove(tmp.Name())
+		return "", false, fmt.Errorf("写入缓存 '%s' 失败: %w", cachePath, writeErr)
+	}
+	return cachePath, false, nil
+}
+
+// fetchRemote 发送 GET 请求并读取响应体，状态码不是 2xx 或响应体超过大小上限时返回错误
+func fetchRemote(client *http.Client, rawURL string, prepare func(*http.Request)) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if prepare != nil {
+		prepare(req)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("返回状态码 %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取响应体失败: %w", err)
+	}
+	if len(data) > maxRemoteFileSize {
+		return nil, fmt.Errorf("文件超过 %dMB 上限", maxRemoteFileSize/(1024*1024))
+	}
+	return data, nil
+}
+
+// remoteCacheName 返回远程地址对应的缓存文件名: 地址哈希前缀加原文件名，同一地址总是使用同一个缓存文件
+func remoteCacheName(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	name := "remote"
+	if u, err := url.Parse(rawURL); err == nil {
+		if base := path.Base(u.Path); base != "" && base != "/" && base != "." {
+			name = base
+		}
+	}
+	return hex.EncodeToString(sum[:8]) + "-" + name
+}
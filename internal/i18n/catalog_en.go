@@ -0,0 +1,188 @@
+package i18n
+
+// english 是英文消息目录: 中文原文 -> 英文译文。
+// 译文的格式动词与原文一一对应，语序不同时使用 %[n]v 形式的显式参数索引
+var english = map[string]string{
+	"错误: 打开 %s 失败: %v\n": "Error: failed to open %s: %v\n",
+	"运行模式: %s\n":         "Mode: %s\n",
+	"配置文件: (内置默认规则)":     "Config file: (built-in default rules)",
+	"配置文件: %s\n":         "Config file: %s\n",
+	"输出目录: %s\n":         "Output directory: %s\n",
+	"HAR 文件: %s\n":       "HAR file: %s\n",
+	"扫描路径: %s\n":         "Scan path: %s\n",
+	"并发度 (文件处理): %d\n":   "Concurrency (file processing): %d\n",
+	"URL 文件: %s\n":       "URL file: %s\n",
+	"扫描 URL: %s\n":       "Scan URL: %s\n",
+	"存储桶: %s\n":          "Bucket: %s\n",
+	"并发度 (URL 请求): %d\n": "Concurrency (URL requests): %d\n",
+	"请求超时: %d 秒\n":       "Request timeout: %d seconds\n",
+	"使用代理: %s\n":         "Using proxy: %s\n",
+	"  请求方法: %s\n":       "  Request method: %s\n",
+	"  自定义 Header: %s\n": "  Custom header: %s\n",
+	"  自定义 Cookie: %s\n": "  Custom cookie: %s\n",
+	"错误: %v\n":           "Error: %v\n",
+	"正在加载和编译规则...":       "Loading and compiling rules...",
+	"提示：未指定配置文件 (-c) 且以下位置都没有配置文件，使用内置的默认规则。可通过 -print-default-rules 导出后自定义。\n  %s\n": "Note: no config file specified (-c) and none found in the following locations; using the built-in default rules. Export them with -print-default-rules to customize.\n  %s\n",
+	"错误: 编译规则失败: %v\n": "Error: failed to compile rules: %v\n",
+	"错误: 配置文件中没有加载到有效的规则。请检查配置文件内容。":                                             "Error: no valid rules were loaded from the config file. Please check its contents.",
+	"错误: 没有规则带有指定的标签 (-tags %s)。请检查规则配置中的 \"tags\" 字段。\n":                        "Error: no rules have the requested tags (-tags %s). Check the \"tags\" field in the rule config.\n",
+	"按标签 (-tags %s) 筛选规则: 跳过了 %d 条不带这些标签的规则。\n":                                  "Filtering rules by tag (-tags %s): skipped %d rules without these tags.\n",
+	"错误: 解析严重级别路径配置 '%s' 失败: %v\n":                                               "Error: failed to parse severity path config '%s': %v\n",
+	"规则加载完成: %d 正则表达式, %d 字面量\n":                                                 "Rules loaded: %d regular expressions, %d literals\n",
+	"已加载 %d 条按路径调整严重级别的配置 (-severity-paths)。\n":                                  "Loaded %d path-based severity overrides (-severity-paths).\n",
+	"已启用注释移除 (-strip-comments): 源码注释中的内容不会被匹配，使用 -v 查看每个来源移除的注释数量。":              "Comment stripping enabled (-strip-comments): content inside source comments is not matched; use -v to see how many comments were removed per source.",
+	"错误: 未知的扫描模式 '%s'\n":                                                         "Error: unknown scan mode '%s'\n",
+	"\n扫描过程中发生错误: %v\n":                                                          "\nAn error occurred during the scan: %v\n",
+	"\n所有扫描任务完成。总执行时间: %v\n":                                                     "\nAll scan tasks finished. Total time: %v\n",
+	"错误: 写入 CPU profile 文件 '%s' 失败: %v\n":                                        "Error: failed to write CPU profile '%s': %v\n",
+	"CPU profile 已写入: %s\n":                                                      "CPU profile written to: %s\n",
+	"内存 profile 已写入: %s\n":                                                       "Memory profile written to: %s\n",
+	"\n收到信号 %v，写出性能分析数据后退出。\n":                                                   "\nReceived signal %v, writing profiling data before exiting.\n",
+	"警告：在 localScan 模式下，URL 相关参数 (-u, -uf) 将被忽略。":                                "Warning: URL options (-u, -uf) are ignored in localScan mode.",
+	"提示：网络文件系统模式 (-network-fs) 未指定 -t，使用默认并发度: %d\n":                             "Note: -t not specified in network filesystem mode (-network-fs), using default concurrency: %d\n",
+	"提示：本地扫描模式未指定 -t，使用默认并发度: %d (CPU核心数 * 2)\n":                                 "Note: -t not specified for local scan, using default concurrency: %d (CPU cores * 2)\n",
+	"警告：-network-fs 模式下不做 MIME 检测，-mime-types 将被忽略。":                             "Warning: MIME detection is disabled with -network-fs; -mime-types is ignored.",
+	"警告：在 urlScan 模式下，本地目录参数 (-d) 将被忽略。":                                         "Warning: the local directory option (-d) is ignored in urlScan mode.",
+	"警告：-stream-body 模式下不做字符集转换，-transcode 将被忽略。":                                "Warning: charset conversion is not done with -stream-body; -transcode is ignored.",
+	"提示：未明确指定模式，但提供了 -d 或 -har-input 参数，假设为 localScan 模式。":                       "Note: no mode specified but -d or -har-input was given, assuming localScan mode.",
+	"提示：未明确指定模式，但提供了 URL 参数 (-u、-uf 或 -bucket)，假设为 urlScan 模式。":                  "Note: no mode specified but a URL option (-u, -uf or -bucket) was given, assuming urlScan mode.",
+	"未指定 -c，使用找到的配置文件: %s\n":                                                     "-c not specified, using config file found at: %s\n",
+	"提示：使用代理 %s\n":                                                               "Note: using proxy %s\n",
+	"警告：代理 %s 不可达，已暂时跳过: %v\n":                                                   "Warning: proxy %s is unreachable, skipping it for now: %v\n",
+	"\n警告：代理 %s 已不可达，暂时跳过: %v\n":                                                 "\nWarning: proxy %s became unreachable, skipping it for now: %v\n",
+	"\n提示：代理 %s 已恢复可用\n":                                                         "\nNote: proxy %s is reachable again\n",
+	"警告：%s，已跳过该规则。\n":                                                            "Warning: %s, rule skipped.\n",
+	"警告：%s，已忽略。\n":                                                               "Warning: %s, ignored.\n",
+	"规则编译完成：加载了 %d 条正则表达式规则，%d 条字面量规则。\n":                                        "Rules compiled: %d regular expression rules, %d literal rules loaded.\n",
+	"有 %d 条规则因配置错误被跳过 (使用 -strict-rules 时任何规则错误都会终止运行)。\n":                       "%d rules were skipped because of config errors (with -strict-rules any rule error aborts the run).\n",
+	"存储桶列表第 %d 页: %d 个对象\n":                                                      "Bucket listing page %d: %d objects\n",
+	"警告: 存储桶列表超过 %d 页，只枚举了前 %d 页的对象。\n":                                          "Warning: bucket listing exceeds %d pages, only objects from the first %d pages were enumerated.\n",
+	"已移除 '%s' 中的 %d 处注释，注释中的内容不会被匹配。\n":                                          "Removed %[2]d comments from '%[1]s'; content inside comments is not matched.\n",
+	"已合并 '%s' 中的 %d 处字符串拼接。\n":                                                   "Joined %[2]d string concatenations in '%[1]s'.\n",
+	"'%s' 大于 %dMB，分为 %d 块并发匹配。\n":                                                "'%s' is larger than %dMB, matching it in %d chunks concurrently.\n",
+	"提示: 规则 '%s' 在 '%s' 中的匹配超过 %d 处，只记录了前 %d 处 (+更多，见 -max-matches-per-rule)。\n": "Note: rule '%[1]s' matched more than %[3]d times in '%[2]s', only the first %[4]d were recorded (+more, see -max-matches-per-rule).\n",
+	"警告: %v\n": "Warning: %v\n",
+	"解码 '%s' 中的 data: URI (%s, %d 字节)，作为 '%s' 扫描。\n":      "Decoding data: URI in '%s' (%s, %d bytes), scanning it as '%s'.\n",
+	"提示: 之前写入失败的 %d 条发现已成功写入结果文件。\n":                      "Note: %d findings that previously failed to write have now been written to the result files.\n",
+	"%d 条发现已写入 SQLite 数据库: %s\n":                          "%d findings written to SQLite database: %s\n",
+	"%d 个没有发现的来源已记录到: %s\n":                               "%d sources without findings recorded to: %s\n",
+	"全局去重: %d 条发现中有 %d 条唯一发现 (规则名 + 匹配内容)，已写入: %s\n":      "Global dedupe: %[2]d unique findings (rule name + match) out of %[1]d, written to: %[3]s\n",
+	"覆盖清单 (%d 个来源) 已写入: %s\n":                             "Coverage manifest (%d sources) written to: %s\n",
+	"\n以下 %d 条发现无法写入结果文件:\n%s":                            "\nThe following %d findings could not be written to the result files:\n%s",
+	"开始扫描 git 差异: %s (仓库: %s)\n":                          "Scanning git diff: %s (repository: %s)\n",
+	"差异中有 %d 个文件新增了共 %d 行。\n":                             "%d files in the diff add %d lines in total.\n",
+	"文件 '%s' 的新增行中未发现匹配项。\n":                              "No matches in the added lines of file '%s'.\n",
+	"发现敏感信息 [%s] -> %s\n":                                 "Sensitive information found [%s] -> %s\n",
+	"git 差异扫描完成。总耗时: %v\n":                                "git diff scan finished. Total time: %v\n",
+	"警告: 读取扩展包 '%s' 中的 '%s' 失败: %v\n":                     "Warning: failed to read '%[2]s' in extension package '%[1]s': %[3]v\n",
+	"开始扫描 HAR 文件: %s (%d 条记录, 并发度: %d)\n":                 "Scanning HAR file: %s (%d entries, concurrency: %d)\n",
+	"HAR 文件扫描完成。总耗时: %v\n":                                "HAR file scan finished. Total time: %v\n",
+	"扫描 HAR 记录: %s %s (状态码: %d)\n":                        "Scanning HAR entry: %s %s (status: %d)\n",
+	"\n自适应并发: %s，并发度调整为 %d\n":                             "\nAdaptive concurrency: %s, concurrency adjusted to %d\n",
+	"开始本地扫描目录: %s (并发度: %d)\n":                            "Starting local directory scan: %s (concurrency: %d)\n",
+	"增量扫描: 只扫描 %s 之后修改过的文件\n":                             "Incremental scan: only scanning files modified after %s\n",
+	"[Worker %d] 启动\n":                                    "[Worker %d] started\n",
+	"[Worker %d] 开始处理: %s\n":                              "[Worker %d] processing: %s\n",
+	"[Worker %d] 完成处理: %s\n":                              "[Worker %d] finished: %s\n",
+	"[Worker %d] 退出\n":                                    "[Worker %d] exiting\n",
+	"错误: 遍历目录 '%s' 时发生错误: %v\n":                           "Error: failed to walk directory '%s': %v\n",
+	"警告: 访问路径 '%s' 出错: %v\n":                              "Warning: error accessing path '%s': %v\n",
+	"跳过文件 (自上次扫描后未修改): %s\n":                              "Skipping file (unchanged since the last scan): %s\n",
+	"跳过文件 (不符合条件): %s\n":                                  "Skipping file (does not match the filters): %s\n",
+	"文件遍历完成，已关闭文件队列。":                                     "Directory walk finished, file queue closed.",
+	"已更新增量扫描状态文件: %s\n":                                   "Incremental scan state file updated: %s\n",
+	"本地扫描完成。总耗时: %v\n":                                    "Local scan finished. Total time: %v\n",
+	"开始本地扫描文件: %s\n":                                      "Starting local file scan: %s\n",
+	"警告: 提取文档 '%s' 的文本失败: %v\n":                           "Warning: failed to extract text from document '%s': %v\n",
+	"警告: 文档 '%s' 的文本超过 %dMB 限制，只处理了部分内容。\n":               "Warning: text of document '%s' exceeds the %dMB limit, only part of it was processed.\n",
+	"警告: 扫描扩展包 '%s' 失败: %v\n":                             "Warning: failed to scan extension package '%s': %v\n",
+	"错误: 读取文件 '%s' 失败: %v\n":                              "Error: failed to read file '%s': %v\n",
+	"跳过空文件: %s\n":                                         "Skipping empty file: %s\n",
+	"警告: 文件 '%s' 看似 gzip 数据但%v，按原始内容扫描。\n":                "Warning: file '%s' looks like gzip data but %v; scanning the raw content.\n",
+	"警告: 文件 '%s' 解压后超过 %dMB 限制，只处理了部分内容。\n":               "Warning: file '%s' exceeds the %dMB limit after decompression, only part of it was processed.\n",
+	"文件 '%s' 未发现匹配项。\n":                                   "No matches in file '%s'.\n",
+	"内存预算不足，等待其他响应处理完成后再读取 URL '%s' 的响应体 (%d 字节)。\n":      "Memory budget exhausted, waiting for other responses to finish before reading the body of URL '%s' (%d bytes).\n",
+	"警告: 刷新缓冲区到 '%s' 失败: %v\n":                            "Warning: failed to flush buffer to '%s': %v\n",
+	"结果文件 '%s' 已存在，跳过 (-on-exist skip)。\n":                "Result file '%s' already exists, skipping (-on-exist skip).\n",
+	"结果文件 '%s' 已存在，改为写入 '%s' (-on-exist rename)。\n":       "Result file '%s' already exists, writing to '%s' instead (-on-exist rename).\n",
+	"\r进度: %d/%d (%.2f%%)":                                "\rProgress: %d/%d (%.2f%%)",
+	" 平均 %.1f 个/秒\033[K":                                  " avg %.1f/s\033[K",
+	" %.1f 个/秒 剩余约 %s\033[K":                              " %.1f/s, about %s remaining\033[K",
+	"警告: 获取远程%s '%s' 失败 (%v)，使用之前的缓存: %s\n":               "Warning: failed to fetch remote %s '%s' (%v), using the previous cache: %s\n",
+	"已下载远程%s '%s'，缓存到: %s\n":                              "Downloaded remote %s '%s', cached at: %s\n",
+	"未命中任何规则 (输入 %d 字节)。\n":                               "No rules matched (input: %d bytes).\n",
+	"[%s]%s 第 %s 行, 偏移 %d: %s\n":                          "[%s]%s line %s, offset %d: %s\n",
+	"    捕获组 %s: <未参与匹配>\n":                               "    group %s: <did not participate>\n",
+	"    捕获组 %s: %s\n":                                    "    group %s: %s\n",
+	"共 %d 处匹配, 命中 %d 条规则。\n":                              "%d matches, %d rules hit.\n",
+	"\n警告: 统计接口异常退出: %v\n":                                "\nWarning: the stats endpoint exited unexpectedly: %v\n",
+	"错误: 等待 URL '%s' 的内存预算失败: %v\n":                       "Error: failed waiting for memory budget for URL '%s': %v\n",
+	"错误: 解析 URL '%s' 响应体的 gzip 头失败: %v\n":                 "Error: failed to parse the gzip header of the body of URL '%s': %v\n",
+	"URL '%s' 的响应体为 gzip 数据，边下载边解压。\n":                    "The body of URL '%s' is gzip data, decompressing while downloading.\n",
+	"错误: 读取 URL '%s' 响应体失败: %v\n":                         "Error: failed to read the body of URL '%s': %v\n",
+	"警告: URL '%s' 的响应体超过 %d 字节限制，只处理了部分内容。\n":             "Warning: the body of URL '%s' exceeds the %d byte limit, only part of it was processed.\n",
+	"URL '%s' 已得到第一个发现 (-first-only)，停止下载 (已读取 %d 字节)。\n": "URL '%s' produced its first finding (-first-only), stopping the download (%d bytes read).\n",
+	"URL '%s' 未发现匹配项。\n":                                  "No matches for URL '%s'.\n",
+	"跳过目录 (已通过其他路径或符号链接扫描过 '%s'): %s\n":                   "Skipping directory (already scanned via another path or symlink as '%s'): %s\n",
+	"从文件 '%s' 加载了 %d 个请求头。\n":                             "Loaded %[2]d headers from file '%[1]s'.\n",
+	"登录完成: 执行了 %d 个登录步骤，%d 个请求头将附加到所有请求。\n":               "Login finished: ran %d login steps, %d headers will be added to all requests.\n",
+	"警告: URL 文件为空。":                                       "Warning: the URL file is empty.",
+	"从文件 '%s' 加载了 %d 个 URL。\n":                            "Loaded %[2]d URLs from file '%[1]s'.\n",
+	"URL '%s' 已在 URL 文件中，不再重复添加。\n":                       "URL '%s' is already in the URL file, not adding it again.\n",
+	"路径字典 '%s' 生成了 %d 个候选 URL。\n":                         "Path wordlist '%s' generated %d candidate URLs.\n",
+	"存储桶 '%s' 中有 %d 个待扫描的对象 (跳过 %d 个非文本或大小超出限制的对象)。\n":    "Bucket '%s' has %d objects to scan (skipped %d non-text or oversized objects).\n",
+	"开始扫描单个 URL: %s (并发度: %d)\n":                          "Scanning a single URL: %s (concurrency: %d)\n",
+	"警告: 没有 URL 需要扫描。":                                    "Warning: no URLs to scan.",
+	"开始扫描 %d 个 URL (并发度: %d)\n":                           "Scanning %d URLs (concurrency: %d)\n",
+	"预检通过: %s\n":                                          "Preflight passed: %s\n",
+	"布隆过滤器去重已启用: 预期 %d 项，误报率 %g，响应体和 URL 各一个过滤器，每个占用 %.1f MB\n":             "Bloom filter dedupe enabled: %d expected items, false positive rate %g, one filter each for bodies and URLs, %.1f MB each\n",
+	"响应体内存预算: %d MB (所有 worker 共享)\n":                                       "Response body memory budget: %d MB (shared by all workers)\n",
+	"自适应并发已启用: 初始并发度 %d，上限 %d\n":                                            "Adaptive concurrency enabled: initial concurrency %d, maximum %d\n",
+	"统计接口已启动: http://%s/stats\n":                                            "Stats endpoint started: http://%s/stats\n",
+	"自适应并发: 结束时并发度为 %d\n":                                                   "Adaptive concurrency: final concurrency %d\n",
+	"%d 个 URL 因 DNS 解析失败 (重试 %d 次后) 未能请求，可能是域名不存在或 DNS 服务器不稳定。\n":           "%d URLs could not be requested because DNS resolution failed (after %d retries); the domain may not exist or the DNS server may be unreliable.\n",
+	"布隆过滤器判定 %d 个 URL 已出现过，未发送请求。\n":                                        "Bloom filter reported %d URLs as already seen; no requests were sent for them.\n",
+	"跳过 %d 个与已扫描响应体内容相同的 URL。\n":                                            "Skipped %d URLs whose bodies were identical to already scanned ones.\n",
+	"HTTP 请求记录已写入 HAR 文件: %s\n":                                             "HTTP requests recorded to HAR file: %s\n",
+	"URL 扫描完成。总耗时: %v\n":                                                    "URL scan finished. Total time: %v\n",
+	"URL '%s' 缺少协议，默认使用 https://\n":                                         "URL '%s' has no scheme, defaulting to https://\n",
+	"错误: 创建请求 '%s' 失败: %v\n":                                                "Error: failed to create request '%s': %v\n",
+	"正在请求 URL: %s (方法: %s)\n":                                               "Requesting URL: %s (method: %s)\n",
+	"HTTPS 请求失败 (%s)，尝试 HTTP: %s\n":                                         "HTTPS request failed (%s), trying HTTP: %s\n",
+	"错误: 请求 URL '%s' 失败 [%s]，已跳过 (可使用 -allow-http-fallback 回退到 HTTP): %v\n": "Error: request to URL '%s' failed [%s], skipped (use -allow-http-fallback to fall back to HTTP): %v\n",
+	"错误: 请求 URL '%s' 失败: %v\n":                                              "Error: request to URL '%s' failed: %v\n",
+	"警告: URL '%s' 返回状态码 %d\n":                                               "Warning: URL '%s' returned status %d\n",
+	"URL '%s' 的响应体 (%d 字节) 小于 -min-body-size，已跳过。\n":                        "The body of URL '%s' (%d bytes) is smaller than -min-body-size, skipped.\n",
+	"警告: URL '%s' 的响应体看似 gzip 数据但%v，按原始内容扫描。\n":                             "Warning: the body of URL '%s' looks like gzip data but %v; scanning the raw content.\n",
+	"警告: URL '%s' 解压后的响应体超过 %d 字节限制，只处理了部分内容。\n":                            "Warning: the decompressed body of URL '%s' exceeds the %d byte limit, only part of it was processed.\n",
+	"URL '%s' 的响应体为 gzip 数据，已自动解压。\n":                                       "The body of URL '%s' is gzip data and was decompressed.\n",
+	"警告: URL '%s' 字符集转换失败 (%v)，按原始内容扫描。\n":                                  "Warning: charset conversion failed for URL '%s' (%v), scanning the raw content.\n",
+	"URL '%s' 的响应体已从 %s 转换为 UTF-8。\n":                                       "The body of URL '%s' was converted from %s to UTF-8.\n",
+	"URL '%s' 响应体为空。\n":                                                     "The body of URL '%s' is empty.\n",
+	"URL '%s' 的响应体已扫描过 (布隆过滤器判定)，跳过扫描。\n":                                   "The body of URL '%s' was already scanned (per the Bloom filter), skipping.\n",
+	"URL '%s' 的响应体与 '%s' 相同，跳过扫描。\n":                                        "The body of URL '%s' is identical to '%s', skipping.\n",
+	"URL '%s' 的 DNS 解析失败，%v 后进行第 %d 次重试: %v\n":                              "DNS resolution for URL '%s' failed, retry %[3]d in %[2]v: %[4]v\n",
+	"配置文件":                       "config file",
+	"严重级别路径配置":                   "severity path config",
+	"跟随 '%s' 中的导入 '%s' 扫描: %s\n": "Following import '%[2]s' in '%[1]s', scanning: %[3]s\n",
+	"跟随导入 (-follow-imports) 额外扫描了 %d 个文件。\n":                          "Following imports (-follow-imports) scanned %d additional files.\n",
+	"\n警告: 结果文件总大小已达到 -max-output-size 上限 (%d MB)，停止写入并取消剩余的扫描。\n":    "\nWarning: result files reached the -max-output-size limit (%d MB); no more findings will be written and the remaining scan is cancelled.\n",
+	"达到 -max-output-size 上限前共写入 %.1f MB 结果 (%d 条发现)，之后的 %d 条发现未写入。\n": "Wrote %.1f MB of results (%d findings) before reaching -max-output-size; %d later findings were not written.\n",
+	"文件 '%s' 与 '%s' 内容相同，跳过扫描。\n":                                     "File '%s' is identical to '%s', skipping.\n",
+	"跳过 %d 个与已扫描文件内容相同的文件 (-dedup-content)，对应关系已写入: %s\n":             "Skipped %d files identical to already scanned ones (-dedup-content), groups written to: %s\n",
+	"严格文件类型模式 (-strict-types): 只扫描扩展名为 %s 的文件，不做 MIME 检测。\n":          "Strict file type mode (-strict-types): only scanning files with extensions %s, no MIME detection.\n",
+	"结果索引 (%d 个来源) 已写入: %s\n":                                         "Result index (%d sources) written to: %s\n",
+	"分层扫描 (-deep): 快速层 %d 条规则，深度层 %d 条规则 (带 \"deep\" 标签)。\n":          "Tiered scan (-deep): %d rules in the fast tier, %d rules in the deep tier (tagged \"deep\").\n",
+	"错误: 所有规则都带有 \"deep\" 标签，需要使用 -deep 启用。":                          "Error: all rules are tagged \"deep\"; enable them with -deep.",
+	"跳过了 %d 条带 \"deep\" 标签的启发式规则，使用 -deep 启用。\n":                      "Skipped %d heuristic rules tagged \"deep\"; enable them with -deep.\n",
+	"规则配置包含 %d 个文件，合并后共 %d 条规则:\n  %s\n":                              "Rule configuration spans %d files, %d rules after merging:\n  %s\n",
+	"  规则 '%s' (来自 %s) 被 %s 中的同名规则覆盖\n":                               "  Rule '%s' (from %s) overridden by the rule of the same name in %s\n",
+	"警告: 获取被包含的规则文件 '%s' 失败 (%v)，使用之前的缓存: %s\n":                       "Warning: failed to fetch included rule file '%s' (%v), using the previous cache: %s\n",
+	"URL '%s' 返回状态码 %d，按 -scan-status 扫描响应体。\n":                       "URL '%s' returned status %d, scanning the body because of -scan-status.\n",
+	"SARIF 报告 (%d 条发现) 已写入: %s\n":                                     "SARIF report (%d findings) written to: %s\n",
+	"SARIF 第 %d/%d 批 (%d 条发现) 已上传到 GitHub 代码扫描 (%s)，上传 ID: %s\n":      "SARIF batch %d/%d (%d findings) uploaded to GitHub code scanning (%s), upload ID: %s\n",
+	"警告: 上传 SARIF 到 GitHub 失败 (%v)，%v 后重试 (%d/%d)。\n":                 "Warning: uploading SARIF to GitHub failed (%v), retrying in %v (%d/%d).\n",
+	"警告: GitHub API 返回状态码 %d，%v 后重试 (%d/%d)。\n":                       "Warning: GitHub API returned status %d, retrying in %v (%d/%d).\n",
+	"提示：-stream-body 模式下发现在下载完成前即写出，不按响应体内容跳过重复的 URL。":                "Note: with -stream-body findings are written before the download completes, so URLs with duplicate response bodies are not skipped.",
+	"URL 文件": "URL file",
+}
@@ -0,0 +1,9 @@
+package rules
+
+import _ "embed"
+
+// DefaultRulesJSON 是内置于程序中的常见密钥规则 (与 config.json 格式相同)，
+// 未通过 -c 指定配置文件且当前目录下没有 config.json 时使用，可通过 -print-default-rules 导出后修改
+//
+//go:embed default_rules.json
+var DefaultRulesJSON string
@@ -0,0 +1,138 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// IncludesKey 是配置文件中包含其他规则文件的指令 (顶层字段，值为路径或 URL 数组)，不作为规则名
+const IncludesKey = "includes"
+
+// IncludeLoader 读取 ref 指向的规则文件，返回文件内容和文件的位置 (本地文件的绝对路径或 URL)；
+// 位置用于循环包含的检测和解析文件中的相对路径
+type IncludeLoader func(ref string) (content, location string, err error)
+
+// RuleOverride 记录合并规则文件时被覆盖的规则
+type RuleOverride struct {
+	Rule string
+	From string // 被覆盖的规则所在的文件
+	By   string // 覆盖它的文件
+}
+
+// IncludeResult 是展开 includes 并合并后的规则配置
+type IncludeResult struct {
+	JSON      string         // 合并后的规则 JSON (不含 includes 指令)，可直接交给 CompileRules
+	Files     []string       // 参与合并的文件位置 (按加载顺序，第一个为入口文件)
+	Rules     int            // 合并后的规则数
+	Overrides []RuleOverride // 被覆盖的规则 (按覆盖发生的顺序)
+}
+
+// ResolveIncludes 从入口文件 ref 开始递归展开 includes 指令并合并所有规则:
+// 被包含的文件按列出的顺序合并，后面的文件覆盖前面同名的规则，文件自身的规则覆盖它包含的所有文件中的同名规则。
+// 相对路径相对于包含它的文件所在的目录 (或 URL) 解析；同一文件可以被多处包含，出现循环包含时返回错误
+func ResolveIncludes(ref string, load IncludeLoader) (*IncludeResult, error) {
+	r := &includeResolver{load: load, seen: make(map[string]bool)}
+	merged, _, err := r.resolve(ref, nil)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("序列化合并后的规则失败: %w", err)
+	}
+	return &IncludeResult{JSON: string(data), Files: r.files, Rules: len(merged), Overrides: r.overrides}, nil
+}
+
+type includeResolver struct {
+	load      IncludeLoader
+	seen      map[string]bool // 已加载过的文件位置
+	files     []string
+	overrides []RuleOverride
+}
+
+// resolve 加载 ref 并合并它包含的文件，返回合并后的规则和每条规则来自的文件；stack 为正在加载的文件链，用于检测循环包含
+func (r *includeResolver) resolve(ref string, stack []string) (map[string]json.RawMessage, map[string]string, error) {
+	content, location, err := r.load(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	if slices.Contains(stack, location) {
+		return nil, nil, fmt.Errorf("规则文件循环包含: %s", strings.Join(append(stack, location), " -> "))
+	}
+	stack = append(stack, location)
+
+	own, includes, err := parseIncludeFile(content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析规则文件 '%s' 失败: %w", location, err)
+	}
+	if !r.seen[location] {
+		r.seen[location] = true
+		r.files = append(r.files, location)
+	}
+
+	merged := make(map[string]json.RawMessage)
+	origins := make(map[string]string)
+	add := func(rules map[string]json.RawMessage, ruleOrigins map[string]string) {
+		for _, name := range slices.Sorted(maps.Keys(rules)) {
+			if from, ok := origins[name]; ok && from != ruleOrigins[name] {
+				override := RuleOverride{Rule: name, From: from, By: ruleOrigins[name]}
+				if !slices.Contains(r.overrides, override) {
+					r.overrides = append(r.overrides, override)
+				}
+			}
+			merged[name] = rules[name]
+			origins[name] = ruleOrigins[name]
+		}
+	}
+	for _, include := range includes {
+		included, includedOrigins, err := r.resolve(resolveIncludeRef(location, include), stack)
+		if err != nil {
+			return nil, nil, err
+		}
+		add(included, includedOrigins)
+	}
+	ownOrigins := make(map[string]string, len(own))
+	for name := range own {
+		ownOrigins[name] = location
+	}
+	add(own, ownOrigins)
+	return merged, origins, nil
+}
+
+// parseIncludeFile 将规则文件解析为规则和 includes 指令中的路径
+func parseIncludeFile(content string) (map[string]json.RawMessage, []string, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &raw); err != nil {
+		return nil, nil, fmt.Errorf("JSON 解码错误: %w", err)
+	}
+	var includes []string
+	if value, ok := raw[IncludesKey]; ok {
+		if err := json.Unmarshal(value, &includes); err != nil {
+			return nil, nil, fmt.Errorf("\"%s\" 必须是路径或 URL 的数组", IncludesKey)
+		}
+		delete(raw, IncludesKey)
+	}
+	return raw, includes, nil
+}
+
+// resolveIncludeRef 将 includes 中的路径解析为相对于包含它的文件 base 的位置: URL 和绝对路径原样使用，
+// base 为 URL 时按 URL 规则解析，否则相对于 base 所在的目录
+func resolveIncludeRef(base, ref string) string {
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") || filepath.IsAbs(ref) {
+		return ref
+	}
+	if strings.HasPrefix(base, "http://") || strings.HasPrefix(base, "https://") {
+		if baseURL, err := url.Parse(base); err == nil {
+			if refURL, err := url.Parse(filepath.ToSlash(ref)); err == nil {
+				return baseURL.ResolveReference(refURL).String()
+			}
+		}
+		return ref
+	}
+	return filepath.Join(filepath.Dir(base), ref)
+}
@@ -3,29 +3,166 @@ package rules
 import (
 	"encoding/json"
 	"fmt"
+	"jsleaksscan/internal/i18n"
+	"maps"
 	"regexp"
+	"regexp/syntax"
+	"slices"
 	"strings"
 )
 
+// 支持的规则严重级别，按从高到低排列
+var Severities = []string{"critical", "high", "medium", "low", "info"}
+
+// RuleMeta 存储规则除模式外的附加信息
+type RuleMeta struct {
+	Severity    string   // 严重级别 (见 Severities)，未设置时为空
+	Description string   // 规则说明
+	Tags        []string // 规则的分类标签 (小写，如 cloud、pii)，未设置时为空
+	Confidence  int      // 规则声明的基础置信度 (1-100)，未设置时为 0
+}
+
 // CompiledRules 存储编译后的规则
 type CompiledRules struct {
 	Regex   map[string]*regexp.Regexp
 	Literal map[string]string
+	Meta    map[string]RuleMeta   // 规则名 -> 附加信息
+	Filters map[string]RuleFilter // 规则名 -> 二次校验 (仅设置了 confirm/deny 的规则)
+	// Transforms 规则名 -> 输出前对匹配内容依次应用的后处理 (仅设置了 transform 的规则)
+	Transforms map[string][]Transform
+	// PathSeverities 按来源路径调整发现的严重级别 (-severity-paths)，按配置顺序匹配
+	PathSeverities []PathSeverity
+	// Tiers 是 -deep 时按快速层、深度层划分的规则 (见 SplitTiers)，依次运行；未分层时为 nil
+	Tiers []RuleTier
 }
 
-// JsonToMap 将 JSON 字符串转换为 map[string]string
-func JsonToMap(jsonStr string) (map[string]string, error) {
+// RuleFilter 对规则的匹配内容做二次校验，用于在不支持环视的 RE2 中降低误报
+type RuleFilter struct {
+	Confirm *regexp.Regexp // 匹配内容必须满足的正则，为 nil 时不校验
+	Deny    *regexp.Regexp // 匹配内容不得满足的正则，为 nil 时不校验
+}
+
+// Allows 判断匹配内容是否通过二次校验
+func (f RuleFilter) Allows(match []byte) bool {
+	if f.Confirm != nil && !f.Confirm.Match(match) {
+		return false
+	}
+	if f.Deny != nil && f.Deny.Match(match) {
+		return false
+	}
+	return true
+}
+
+// RuleSpec 是配置文件中的单条规则
+// 值可以是模式字符串 (简单格式)，也可以是包含 pattern 及附加字段的对象 (扩展格式)
+type RuleSpec struct {
+	Pattern     string `json:"pattern"`
+	Severity    string `json:"severity,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Tags 规则的分类标签 (如 ["cloud", "crypto"])，可通过 -tags 只启用带有指定标签的规则
+	Tags []string `json:"tags,omitempty"`
+	// Type 强制指定模式类型: "literal" 或 "regex"，为空时自动判断
+	Type string `json:"type,omitempty"`
+	// Confirm 和 Deny 是对匹配内容的二次校验正则：匹配内容必须满足 Confirm 且不得满足 Deny
+	Confirm string `json:"confirm,omitempty"`
+	Deny    string `json:"deny,omitempty"`
+	// Transform 输出前对匹配内容的后处理，多个名称以逗号分隔，按顺序应用 (如 "trim-quotes,url-decode")
+	Transform string `json:"transform,omitempty"`
+	// Confidence 规则的基础置信度 (1-100)，发现的置信度在此基础上按匹配内容的熵等信号调整，未设置时使用默认值
+	Confidence int `json:"confidence,omitempty"`
+}
+
+// 规则模式类型
+const (
+	PatternTypeAuto    = ""
+	PatternTypeLiteral = "literal"
+	PatternTypeRegex   = "regex"
+)
+
+// UnmarshalJSON 同时支持简单格式 ("name": "pattern") 和扩展格式 ("name": {"pattern": ...})
+func (r *RuleSpec) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &r.Pattern)
+	}
+	type plain RuleSpec // 避免递归调用 UnmarshalJSON
+	return json.Unmarshal(data, (*plain)(r))
+}
+
+// RuleProblem 是规则配置中的一个问题
+type RuleProblem struct {
+	Rule    string
+	Message string
+	Skipped bool // 非严格模式下整条规则被跳过；为 false 时只忽略有问题的字段，规则仍然生效
+}
+
+func (p RuleProblem) String() string {
+	return fmt.Sprintf("规则 '%s': %s", p.Rule, p.Message)
+}
+
+// JsonToRuleSpecs 将 JSON 字符串转换为规则名到规则定义的映射
+// 每条规则先按扩展格式的字段定义校验 (见 validateRuleSpec)，存在未知字段或类型错误的规则不包含在返回的映射中，
+// 其全部问题在 problems 中返回；JSON 本身无法解析时返回错误
+func JsonToRuleSpecs(jsonStr string) (specs map[string]RuleSpec, problems []RuleProblem, err error) {
 	// 预估 map 大小以提高性能
 	estimatedPairs := strings.Count(jsonStr, ":")
-	m := make(map[string]string, estimatedPairs)
+	raw := make(map[string]json.RawMessage, estimatedPairs)
 	// 使用 Decoder 处理可能更健壮
 	decoder := json.NewDecoder(strings.NewReader(jsonStr))
-	if err := decoder.Decode(&m); err != nil {
-		return nil, fmt.Errorf("JSON 解码错误: %w", err)
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, nil, fmt.Errorf("JSON 解码错误: %w", err)
+	}
+
+	specs = make(map[string]RuleSpec, len(raw))
+	for _, name := range slices.Sorted(maps.Keys(raw)) {
+		invalid := validateRuleSpec(raw[name])
+		for _, message := range invalid {
+			problems = append(problems, RuleProblem{Rule: name, Message: message, Skipped: true})
+		}
+		if len(invalid) > 0 {
+			continue
+		}
+		var spec RuleSpec
+		if err := json.Unmarshal(raw[name], &spec); err != nil {
+			problems = append(problems, RuleProblem{Rule: name, Message: err.Error(), Skipped: true})
+			continue
+		}
+		specs[name] = spec
+	}
+	return specs, problems, nil
+}
+
+// JsonToMap 将 JSON 字符串转换为规则名到模式的映射，任何一条规则无效时返回错误
+//
+// Deprecated: 使用 JsonToRuleSpecs，它支持扩展格式的规则字段并逐条报告问题。
+func JsonToMap(jsonStr string) (map[string]string, error) {
+	specs, problems, err := JsonToRuleSpecs(jsonStr)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("规则 '%s': %s", problems[0].Rule, problems[0].Message)
+	}
+	m := make(map[string]string, len(specs))
+	for name, spec := range specs {
+		m[name] = spec.Pattern
 	}
 	return m, nil
 }
 
+// normalizeSeverity 校验并规范化严重级别，无效值返回 false
+func normalizeSeverity(severity string) (string, bool) {
+	severity = strings.ToLower(strings.TrimSpace(severity))
+	if severity == "" {
+		return "", true
+	}
+	for _, known := range Severities {
+		if severity == known {
+			return severity, true
+		}
+	}
+	return "", false
+}
+
 // isLiteralPattern 检查一个字符串是否可以被视为字面量模式（不包含正则元字符）
 // 注意：这个检查可能不完全准确，复杂的字面量可能误判为正则
 func isLiteralPattern(pattern string) bool {
@@ -34,41 +171,271 @@ func isLiteralPattern(pattern string) bool {
 	return !strings.ContainsAny(pattern, ".+*?()|[]{}^$") && !strings.Contains(pattern, `\`)
 }
 
+// literalFromPattern 在自动判断模式下识别含反斜杠的字面量，返回实际要匹配的文本
+//   - 解析结果只是一段固定文本的正则 (如 `api\.example\.com`、`\/api\/v1`、`\x41`) 等价于字面量，
+//     返回从语法树还原的文本，与按正则匹配的结果完全一致
+//   - 不含其他元字符、但反斜杠不构成合法转义的模式 (如 Windows 路径 `C:\Users`) 按原样视为字面量
+func literalFromPattern(pattern string) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err == nil {
+		if re.Op == syntax.OpLiteral && re.Flags&syntax.FoldCase == 0 {
+			return string(re.Rune), true
+		}
+		return "", false
+	}
+	if !strings.ContainsAny(pattern, ".+*?()|[]{}^$") {
+		return pattern, true
+	}
+	return "", false
+}
+
+// classifyPattern 根据规则的 Type 字段和模式内容决定按字面量还是正则处理
+// 返回值 literal 为字面量规则实际匹配的文本
+func classifyPattern(spec RuleSpec) (literal string, isLiteral bool, err error) {
+	switch strings.ToLower(strings.TrimSpace(spec.Type)) {
+	case PatternTypeLiteral:
+		return spec.Pattern, true, nil
+	case PatternTypeRegex:
+		return "", false, nil
+	case PatternTypeAuto:
+		if isLiteralPattern(spec.Pattern) {
+			return spec.Pattern, true, nil
+		}
+		literal, isLiteral = literalFromPattern(spec.Pattern)
+		return literal, isLiteral, nil
+	default:
+		return "", false, fmt.Errorf("类型 '%s' 无效 (可选: %s/%s)", spec.Type, PatternTypeLiteral, PatternTypeRegex)
+	}
+}
+
+// compileFilter 编译规则的 confirm/deny 正则，两者都未设置时返回 nil
+func compileFilter(spec RuleSpec) (*RuleFilter, error) {
+	if spec.Confirm == "" && spec.Deny == "" {
+		return nil, nil
+	}
+	filter := &RuleFilter{}
+	if spec.Confirm != "" {
+		re, err := regexp.Compile(spec.Confirm)
+		if err != nil {
+			return nil, fmt.Errorf("confirm 正则 '%s' 编译失败: %w", spec.Confirm, err)
+		}
+		filter.Confirm = re
+	}
+	if spec.Deny != "" {
+		re, err := regexp.Compile(spec.Deny)
+		if err != nil {
+			return nil, fmt.Errorf("deny 正则 '%s' 编译失败: %w", spec.Deny, err)
+		}
+		filter.Deny = re
+	}
+	return filter, nil
+}
+
+// CompileOptions 控制规则的编译方式
+type CompileOptions struct {
+	// Multiline 为 true 时所有正则表达式启用 (?s) 模式，使 . 可以匹配换行符，
+	// 无需逐条修改规则即可匹配跨行内容 (例如 PEM 私钥块)
+	Multiline bool
+	// Strict 为 true 时任何一条规则有错误 (包括无效的严重级别等字段) 都返回错误；
+	// 否则有错误的规则被跳过 (无效的可选字段被忽略) 并打印警告，其余规则正常加载
+	Strict bool
+}
+
 // CompileRules 从 JSON 字符串编译规则
-func CompileRules(ruleJsonStr string) (*CompiledRules, error) {
-	ruleMap, err := JsonToMap(ruleJsonStr)
+func CompileRules(ruleJsonStr string, opts CompileOptions) (*CompiledRules, error) {
+	ruleMap, problems, err := JsonToRuleSpecs(ruleJsonStr)
 	if err != nil {
 		return nil, fmt.Errorf("解析规则 JSON 失败: %w", err)
 	}
 
 	compiled := &CompiledRules{
-		Regex:   make(map[string]*regexp.Regexp),
-		Literal: make(map[string]string),
+		Regex:      make(map[string]*regexp.Regexp),
+		Literal:    make(map[string]string),
+		Meta:       make(map[string]RuleMeta),
+		Filters:    make(map[string]RuleFilter),
+		Transforms: make(map[string][]Transform),
 	}
 
-	for name, pattern := range ruleMap {
+	for _, name := range slices.Sorted(maps.Keys(ruleMap)) {
+		spec := ruleMap[name]
+		skip := func(format string, args ...any) {
+			problems = append(problems, RuleProblem{Rule: name, Message: fmt.Sprintf(format, args...), Skipped: true})
+		}
+		pattern := spec.Pattern
 		if pattern == "" {
-			fmt.Printf("警告：规则 '%s' 的模式为空，已跳过。\n", name)
+			skip("模式为空")
 			continue // 跳过空模式
 		}
-		if isLiteralPattern(pattern) {
-			compiled.Literal[name] = pattern
+		literal, isLiteral, err := classifyPattern(spec)
+		if err != nil {
+			skip("%v", err)
+			continue
+		}
+		filter, err := compileFilter(spec)
+		if err != nil {
+			skip("%v", err)
+			continue
+		}
+		transforms, err := compileTransforms(spec)
+		if err != nil {
+			skip("%v", err)
+			continue
+		}
+		if isLiteral {
+			compiled.Literal[name] = literal
 		} else {
-			// 尝试编译为正则表达式
-			// 为提高性能，可以考虑使用 regexp.MustCompile，但这会在编译失败时 panic
-			reg, err := regexp.Compile(pattern)
+			// 尝试编译为正则表达式；编译失败的规则被跳过，而不是回退为字面量 (需要按字面量匹配时可设置 "type": "literal")
+			regexPattern := pattern
+			if opts.Multiline {
+				regexPattern = "(?s)" + pattern
+			}
+			reg, err := regexp.Compile(regexPattern)
 			if err != nil {
-				// 如果编译失败，可以考虑将其视为字面量，或者报错
-				fmt.Printf("警告：编译规则 '%s' 的正则表达式 '%s' 失败: %v。将尝试作为字面量处理。\n", name, pattern, err)
-				// 或者选择报错并退出：
-				// return nil, fmt.Errorf("编译规则 '%s' 的正则表达式失败: %w", name, err)
-				compiled.Literal[name] = pattern // 编译失败则视为字面量
-			} else {
-				compiled.Regex[name] = reg
+				skip("正则表达式 '%s' 编译失败: %v", pattern, err)
+				continue
 			}
+			compiled.Regex[name] = reg
+		}
+
+		severity, ok := normalizeSeverity(spec.Severity)
+		if !ok {
+			problems = append(problems, RuleProblem{Rule: name, Message: fmt.Sprintf("严重级别 '%s' 无效 (可选: %s)", spec.Severity, strings.Join(Severities, "/"))})
+		}
+		confidence := spec.Confidence
+		if confidence < 0 || confidence > 100 {
+			problems = append(problems, RuleProblem{Rule: name, Message: fmt.Sprintf("置信度 %d 无效 (应为 1-100)", spec.Confidence)})
+			confidence = 0
+		}
+		compiled.Meta[name] = RuleMeta{Severity: severity, Description: spec.Description, Tags: NormalizeTags(spec.Tags), Confidence: confidence}
+		if filter != nil {
+			compiled.Filters[name] = *filter
+		}
+		if len(transforms) > 0 {
+			compiled.Transforms[name] = transforms
 		}
 	}
 
-	fmt.Printf("规则编译完成：加载了 %d 条正则表达式规则，%d 条字面量规则。\n", len(compiled.Regex), len(compiled.Literal))
+	// 按规则名排列，同一规则的问题保持发现的顺序
+	slices.SortStableFunc(problems, func(a, b RuleProblem) int { return strings.Compare(a.Rule, b.Rule) })
+	if opts.Strict && len(problems) > 0 {
+		lines := make([]string, len(problems))
+		for i, problem := range problems {
+			lines[i] = problem.String()
+		}
+		return nil, fmt.Errorf("规则配置中有 %d 处错误 (-strict-rules):\n  %s", len(problems), strings.Join(lines, "\n  "))
+	}
+	skipped := make(map[string]bool)
+	for _, problem := range problems {
+		if problem.Skipped {
+			i18n.Printf("警告：%s，已跳过该规则。\n", problem)
+			skipped[problem.Rule] = true
+		} else {
+			i18n.Printf("警告：%s，已忽略。\n", problem)
+		}
+	}
+
+	i18n.Printf("规则编译完成：加载了 %d 条正则表达式规则，%d 条字面量规则。\n", len(compiled.Regex), len(compiled.Literal))
+	if len(skipped) > 0 {
+		i18n.Printf("有 %d 条规则因配置错误被跳过 (使用 -strict-rules 时任何规则错误都会终止运行)。\n", len(skipped))
+	}
 	return compiled, nil
 }
+
+// NormalizeTags 将标签转换为小写并去除空白、空项和重复项，保持原有顺序
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// HasAnyTag 判断规则是否带有 tags 中的任一标签 (tags 须已规范化)
+func (m RuleMeta) HasAnyTag(tags []string) bool {
+	for _, tag := range m.Tags {
+		if slices.Contains(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterByTags 只保留带有 tags 中任一标签的规则 (-tags)，返回被移除的规则数
+func (c *CompiledRules) FilterByTags(tags []string) int {
+	removed := 0
+	for name, meta := range c.Meta {
+		if meta.HasAnyTag(tags) {
+			continue
+		}
+		delete(c.Regex, name)
+		delete(c.Literal, name)
+		delete(c.Meta, name)
+		delete(c.Filters, name)
+		delete(c.Transforms, name)
+		removed++
+	}
+	return removed
+}
+
+// 分层扫描 (-deep): 带有 DeepTag 标签的规则 (熵、通用模式等噪声较多的启发式规则) 属于深度层，
+// 默认不启用；指定 -deep 时在快速层 (其余规则) 之后单独运行，发现标注产生它的层
+const (
+	DeepTag  = "deep"
+	TierFast = "fast"
+	TierDeep = "deep"
+)
+
+// RuleTier 是分层扫描中一层的规则
+type RuleTier struct {
+	Name    string // TierFast 或 TierDeep
+	Regex   map[string]*regexp.Regexp
+	Literal map[string]string
+}
+
+// SplitTiers 按 DeepTag 将规则划分为快速层和深度层 (写入 Tiers)，返回两层的规则数。
+// Regex、Literal 仍包含全部规则，供按规则名查找
+func (c *CompiledRules) SplitTiers() (fast, deep int) {
+	tiers := []RuleTier{
+		{Name: TierFast, Regex: make(map[string]*regexp.Regexp), Literal: make(map[string]string)},
+		{Name: TierDeep, Regex: make(map[string]*regexp.Regexp), Literal: make(map[string]string)},
+	}
+	tierOf := func(name string) *RuleTier {
+		if slices.Contains(c.Meta[name].Tags, DeepTag) {
+			deep++
+			return &tiers[1]
+		}
+		fast++
+		return &tiers[0]
+	}
+	for name, re := range c.Regex {
+		tierOf(name).Regex[name] = re
+	}
+	for name, literal := range c.Literal {
+		tierOf(name).Literal[name] = literal
+	}
+	c.Tiers = tiers
+	return fast, deep
+}
+
+// RemoveDeep 移除深度层的规则 (未指定 -deep 时)，返回被移除的规则数
+func (c *CompiledRules) RemoveDeep() int {
+	removed := 0
+	for name, meta := range c.Meta {
+		if !slices.Contains(meta.Tags, DeepTag) {
+			continue
+		}
+		delete(c.Regex, name)
+		delete(c.Literal, name)
+		delete(c.Meta, name)
+		delete(c.Filters, name)
+		delete(c.Transforms, name)
+		removed++
+	}
+	return removed
+}
@@ -0,0 +1,67 @@
+package rules
+
+import "testing"
+
+func TestLiteralFromPattern(t *testing.T) {
+	tests := []struct {
+		pattern     string
+		wantLiteral string
+		wantOK      bool
+	}{
+		{pattern: `api\.example\.com`, wantLiteral: "api.example.com", wantOK: true},
+		{pattern: `\$9\.99 \(USD\)`, wantLiteral: "$9.99 (USD)", wantOK: true},
+		{pattern: `C:\\Windows`, wantLiteral: `C:\Windows`, wantOK: true},
+		{pattern: `C:\Users\admin`, wantLiteral: `C:\Users\admin`, wantOK: true},
+		{pattern: `\/api\/v1`, wantLiteral: "/api/v1", wantOK: true},
+		{pattern: `x-api\-key`, wantLiteral: "x-api-key", wantOK: true},
+		{pattern: `https\:\/\/`, wantLiteral: "https://", wantOK: true},
+		{pattern: `\x41KIA`, wantLiteral: "AKIA", wantOK: true},
+		{pattern: `token\tvalue`, wantLiteral: "token\tvalue", wantOK: true},
+		{pattern: `\Qa.b\E`, wantLiteral: "a.b", wantOK: true},
+		{pattern: `api\.example\.com/v\d+`, wantOK: false},
+		{pattern: `key-\w{16}`, wantOK: false},
+		{pattern: `(?i)secret`, wantOK: false},
+		{pattern: `C:\Users\.*`, wantOK: false},
+	}
+	for _, tt := range tests {
+		literal, ok := literalFromPattern(tt.pattern)
+		if ok != tt.wantOK || literal != tt.wantLiteral {
+			t.Errorf("literalFromPattern(%q) = (%q, %v), 期望 (%q, %v)", tt.pattern, literal, ok, tt.wantLiteral, tt.wantOK)
+		}
+	}
+}
+
+func TestCompileRulesEscapedLiteral(t *testing.T) {
+	compiled, err := CompileRules(`{"win_path": "C:\\Users\\admin", "api": "\\/api\\/v1", "host": "api\\.example\\.com", "forced": {"pattern": "a.b", "type": "literal"}}`, CompileOptions{})
+	if err != nil {
+		t.Fatalf("CompileRules: %v", err)
+	}
+	want := map[string]string{"win_path": `C:\Users\admin`, "api": "/api/v1", "host": "api.example.com", "forced": "a.b"}
+	for name, literal := range want {
+		if got, ok := compiled.Literal[name]; !ok || got != literal {
+			t.Errorf("规则 %s 的字面量 = %q (存在: %v), 期望 %q", name, got, ok, literal)
+		}
+	}
+}
+
+func TestJsonToMap(t *testing.T) {
+	m, err := JsonToMap(`{"aws_key": "AKIA[0-9A-Z]{16}", "token": {"pattern": "tok_[a-z]+", "severity": "high"}}`)
+	if err != nil {
+		t.Fatalf("JsonToMap: %v", err)
+	}
+	want := map[string]string{"aws_key": "AKIA[0-9A-Z]{16}", "token": "tok_[a-z]+"}
+	if len(m) != len(want) {
+		t.Fatalf("JsonToMap = %v, 期望 %v", m, want)
+	}
+	for name, pattern := range want {
+		if m[name] != pattern {
+			t.Errorf("规则 %s 的模式 = %q, 期望 %q", name, m[name], pattern)
+		}
+	}
+	if _, err := JsonToMap(`{"bad": 42}`); err == nil {
+		t.Error("无效规则没有返回错误")
+	}
+	if _, err := JsonToMap(`{`); err == nil {
+		t.Error("无效 JSON 没有返回错误")
+	}
+}
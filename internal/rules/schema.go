@@ -0,0 +1,104 @@
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ruleSpecFields 返回扩展格式中可用的字段 (RuleSpec 的 JSON 字段名 -> Go 类型)，按定义顺序排列的字段名用于错误提示
+var ruleSpecFields = sync.OnceValues(func() (map[string]reflect.Type, []string) {
+	fields := make(map[string]reflect.Type)
+	var names []string
+	t := reflect.TypeFor[RuleSpec]()
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = t.Field(i).Type
+		names = append(names, name)
+	}
+	return fields, names
+})
+
+// validateRuleSpec 按扩展格式的字段定义校验单条规则的原始 JSON，返回发现的全部问题 (而非只返回第一个)：
+// 值必须是字符串 (简单格式) 或对象 (扩展格式)，对象中不能有未知字段，每个字段的类型必须正确。
+// 字段名与 encoding/json 一样不区分大小写
+func validateRuleSpec(raw json.RawMessage) []string {
+	switch jsonKind(raw) {
+	case "字符串":
+		return nil
+	case "对象":
+	default:
+		return []string{fmt.Sprintf("值应为模式字符串或对象，实际为%s", jsonKind(raw))}
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return []string{err.Error()}
+	}
+	fields, names := ruleSpecFields()
+	var problems []string
+	for _, key := range slices.Sorted(maps.Keys(object)) {
+		fieldType, ok := fields[key]
+		if !ok {
+			for _, name := range names {
+				if strings.EqualFold(name, key) {
+					fieldType, ok = fields[name], true
+					break
+				}
+			}
+		}
+		if !ok {
+			problems = append(problems, fmt.Sprintf("未知字段 \"%s\" (可用字段: %s)", key, strings.Join(names, ", ")))
+			continue
+		}
+		value := object[key]
+		if err := json.Unmarshal(value, reflect.New(fieldType).Interface()); err != nil {
+			problems = append(problems, fmt.Sprintf("字段 \"%s\" 的类型错误: 应为%s，实际为%s", key, describeType(fieldType), jsonKind(value)))
+		}
+	}
+	return problems
+}
+
+// describeType 返回字段类型的中文描述，用于错误提示
+func describeType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "字符串"
+	case reflect.Int:
+		return "整数"
+	case reflect.Slice:
+		return describeType(t.Elem()) + "数组"
+	default:
+		return t.String()
+	}
+}
+
+// jsonKind 返回 JSON 值的类型描述，用于错误提示
+func jsonKind(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return "空值"
+	}
+	switch raw[0] {
+	case '"':
+		return "字符串"
+	case '{':
+		return "对象"
+	case '[':
+		return "数组"
+	case 't', 'f':
+		return "布尔值"
+	case 'n':
+		return "null"
+	default:
+		return "数字 " + string(raw)
+	}
+}
@@ -0,0 +1,142 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PathSeveritySpec 是按路径调整严重级别的配置文件 (-severity-paths) 中的一项
+type PathSeveritySpec struct {
+	// Path 为来源路径的 glob 模式: * 匹配除 / 外的任意字符，** 匹配任意层目录，以 / 结尾表示该目录下的所有文件
+	Path string `json:"path"`
+	// Severity 为严重级别 (如 "low"，直接替换) 或带符号的级别偏移 (如 "-1" 降低一级，"+1" 提高一级)
+	Severity string `json:"severity"`
+	// Rules 限定只调整这些规则的发现，为空时调整所有规则
+	Rules []string `json:"rules,omitempty"`
+}
+
+// PathSeverity 是编译后的路径严重级别调整
+type PathSeverity struct {
+	Pattern  string
+	re       *regexp.Regexp
+	override string // 非空时直接替换为该级别
+	shift    int    // override 为空时的级别偏移，正数表示提高
+	rules    map[string]bool
+}
+
+// CompilePathSeverities 解析 -severity-paths 配置 (JSON 数组)，按文件中的顺序返回
+func CompilePathSeverities(jsonStr string) ([]PathSeverity, error) {
+	var specs []PathSeveritySpec
+	if err := json.Unmarshal([]byte(jsonStr), &specs); err != nil {
+		return nil, fmt.Errorf("JSON 解码错误: %w", err)
+	}
+	compiled := make([]PathSeverity, 0, len(specs))
+	for i, spec := range specs {
+		if strings.TrimSpace(spec.Path) == "" {
+			return nil, fmt.Errorf("第 %d 项的 path 为空", i+1)
+		}
+		entry := PathSeverity{Pattern: spec.Path, re: globToRegexp(spec.Path)}
+		value := strings.TrimSpace(spec.Severity)
+		if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-") {
+			shift, err := strconv.Atoi(value)
+			if err != nil || shift == 0 {
+				return nil, fmt.Errorf("第 %d 项的级别偏移 '%s' 无效", i+1, spec.Severity)
+			}
+			entry.shift = shift
+		} else {
+			severity, ok := normalizeSeverity(value)
+			if !ok || severity == "" {
+				return nil, fmt.Errorf("第 %d 项的严重级别 '%s' 无效 (可选: %s，或 -1/+1 等级别偏移)", i+1, spec.Severity, strings.Join(Severities, "/"))
+			}
+			entry.override = severity
+		}
+		if len(spec.Rules) > 0 {
+			entry.rules = make(map[string]bool, len(spec.Rules))
+			for _, name := range spec.Rules {
+				entry.rules[name] = true
+			}
+		}
+		compiled = append(compiled, entry)
+	}
+	return compiled, nil
+}
+
+// globToRegexp 将路径 glob 转换为正则表达式，模式可以匹配来源路径中从任意一级目录开始的部分
+func globToRegexp(glob string) *regexp.Regexp {
+	glob = strings.TrimPrefix(filepath.ToSlash(glob), "/")
+	if strings.HasSuffix(glob, "/") {
+		glob += "**"
+	}
+	var b strings.Builder
+	b.WriteString("(^|/)")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					b.WriteString("(.*/)?") // "**/" 可以匹配零层目录
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// sourcePath 返回来源标识中用于路径匹配的部分:
+// URL 取主机和路径，本地路径统一为 / 分隔，去掉 "#text" 等后缀并将扩展包分隔符 "!" 视为目录
+func sourcePath(source string) string {
+	if u, err := url.Parse(source); err == nil && u.Host != "" {
+		return u.Host + u.Path
+	}
+	source, _, _ = strings.Cut(source, "#")
+	return strings.ReplaceAll(filepath.ToSlash(source), "!", "/")
+}
+
+// AdjustSeverity 按路径严重级别配置调整来源 source 中规则 rule 的发现的严重级别
+// 只应用第一条匹配的配置项；级别偏移不会超出 critical..info 范围，也不作用于未设置严重级别的规则
+func AdjustSeverity(adjustments []PathSeverity, source, rule, severity string) string {
+	if len(adjustments) == 0 {
+		return severity
+	}
+	path := sourcePath(source)
+	for _, adj := range adjustments {
+		if adj.rules != nil && !adj.rules[rule] {
+			continue
+		}
+		if !adj.re.MatchString(path) {
+			continue
+		}
+		if adj.override != "" {
+			return adj.override
+		}
+		return shiftSeverity(severity, adj.shift)
+	}
+	return severity
+}
+
+// shiftSeverity 将严重级别提高 (shift > 0) 或降低 (shift < 0) 若干级
+func shiftSeverity(severity string, shift int) string {
+	for i, known := range Severities {
+		if known == severity {
+			// Severities 按从高到低排列，提高级别对应减小下标
+			return Severities[min(max(i-shift, 0), len(Severities)-1)]
+		}
+	}
+	return severity
+}
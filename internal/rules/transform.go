@@ -0,0 +1,72 @@
+package rules
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Transform 是对匹配内容的后处理，在输出前规范化匹配内容
+type Transform func(string) string
+
+// builtinTransforms 内置的后处理，按名称在规则的 transform 字段中引用
+var builtinTransforms = map[string]Transform{
+	"url-decode":  urlDecode,
+	"lowercase":   strings.ToLower,
+	"uppercase":   strings.ToUpper,
+	"trim-space":  strings.TrimSpace,
+	"trim-quotes": trimQuotes,
+}
+
+// TransformNames 返回所有内置后处理的名称 (已排序)
+func TransformNames() []string {
+	names := make([]string, 0, len(builtinTransforms))
+	for name := range builtinTransforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// urlDecode 对匹配内容做百分号解码 (+ 解码为空格)，解码失败时保持原样
+func urlDecode(s string) string {
+	decoded, err := url.QueryUnescape(s)
+	if err != nil {
+		return s
+	}
+	return decoded
+}
+
+// trimQuotes 去掉首尾成对的引号 (" ' `)，可以重复去除多层
+func trimQuotes(s string) string {
+	for len(s) >= 2 && strings.ContainsRune("\"'`", rune(s[0])) && s[len(s)-1] == s[0] {
+		s = s[1 : len(s)-1]
+	}
+	return s
+}
+
+// compileTransforms 解析规则的 transform 字段 (逗号分隔，按顺序依次应用)，未设置时返回 nil
+func compileTransforms(spec RuleSpec) ([]Transform, error) {
+	var transforms []Transform
+	for _, name := range strings.Split(spec.Transform, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		transform, ok := builtinTransforms[name]
+		if !ok {
+			return nil, fmt.Errorf("transform '%s' 无效 (可选: %s)", name, strings.Join(TransformNames(), "/"))
+		}
+		transforms = append(transforms, transform)
+	}
+	return transforms, nil
+}
+
+// ApplyTransforms 按顺序对匹配内容应用规则的后处理
+func ApplyTransforms(transforms []Transform, match string) string {
+	for _, transform := range transforms {
+		match = transform(match)
+	}
+	return match
+}
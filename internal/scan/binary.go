@@ -0,0 +1,57 @@
+package scan
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 含二进制字节的匹配内容的输出编码 (-binary-match)
+const (
+	binaryMatchRaw    = "raw"    // 按原样输出
+	binaryMatchHex    = "hex"    // 不可打印字节和无效 UTF-8 字节转义为 \xNN，其余字符保持不变
+	binaryMatchBase64 = "base64" // 整个匹配内容以标准 base64 编码输出
+)
+
+// isBinaryMatch 判断匹配内容是否包含无效的 UTF-8 序列或控制字符 (制表符和换行符除外)
+func isBinaryMatch(match string) bool {
+	if !utf8.ValidString(match) {
+		return true
+	}
+	for _, r := range match {
+		if r != '\t' && r != '\n' && r != '\r' && unicode.IsControl(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeBinaryMatch 按 mode 编码含二进制字节的匹配内容，返回编码后的内容和所用编码；
+// 匹配内容是普通文本或 mode 为 raw 时原样返回，编码为空
+func encodeBinaryMatch(match, mode string) (string, string) {
+	if mode == "" || mode == binaryMatchRaw || !isBinaryMatch(match) {
+		return match, ""
+	}
+	if mode == binaryMatchBase64 {
+		return base64.StdEncoding.EncodeToString([]byte(match)), binaryMatchBase64
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(match); {
+		r, size := utf8.DecodeRuneInString(match[i:])
+		switch {
+		case r == utf8.RuneError && size <= 1, r != '\t' && r != '\n' && r != '\r' && unicode.IsControl(r):
+			for _, c := range []byte(match[i : i+size]) {
+				fmt.Fprintf(&b, `\x%02x`, c)
+			}
+		case r == '\\':
+			b.WriteString(`\\`) // 转义反斜杠本身，使 \xNN 不产生歧义
+		default:
+			b.WriteString(match[i : i+size])
+		}
+		i += size
+	}
+	return b.String(), binaryMatchHex
+}
@@ -0,0 +1,58 @@
+package scan
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"sync"
+)
+
+// bloomFilter 是固定内存的概率集合，用于在超大规模扫描中代替精确的已见集合 (-bloom)
+// 判断为"未见过"时一定未见过；判断为"见过"时有很小的概率误判 (误报率由创建时的参数决定)，
+// 误判会导致少量未见过的内容或 URL 被跳过。可被多个 goroutine 并发使用
+type bloomFilter struct {
+	mu   sync.Mutex
+	bits []uint64
+	m    uint64 // 位数
+	k    uint64 // 哈希函数个数
+}
+
+// newBloomFilter 按预期元素数 n 和目标误报率 p 计算位数和哈希函数个数并创建过滤器
+// 元素数超过 n 后实际误报率会逐渐升高
+func newBloomFilter(n int, p float64) *bloomFilter {
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	m = max(64, (m+63)/64*64)
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	k = max(1, k)
+	return &bloomFilter{bits: make([]uint64, m/64), m: m, k: k}
+}
+
+// sizeBytes 返回过滤器位数组占用的内存 (字节)
+func (bf *bloomFilter) sizeBytes() int {
+	return len(bf.bits) * 8
+}
+
+// add 将哈希加入过滤器，返回加入前是否 (可能) 已存在
+// 使用双重哈希 h1 + i*h2 从 SHA-256 摘要派生 k 个位置
+func (bf *bloomFilter) add(hash [sha256.Size]byte) bool {
+	h1 := binary.LittleEndian.Uint64(hash[0:8])
+	h2 := binary.LittleEndian.Uint64(hash[8:16]) | 1 // 保证步长为奇数
+
+	bf.mu.Lock()
+	defer bf.mu.Unlock()
+	present := true
+	for i := uint64(0); i < bf.k; i++ {
+		pos := (h1 + i*h2) % bf.m
+		word, bit := pos/64, uint64(1)<<(pos%64)
+		if bf.bits[word]&bit == 0 {
+			present = false
+			bf.bits[word] |= bit
+		}
+	}
+	return present
+}
+
+// addString 将字符串的哈希加入过滤器，返回加入前是否 (可能) 已存在
+func (bf *bloomFilter) addString(s string) bool {
+	return bf.add(sha256.Sum256([]byte(s)))
+}
@@ -0,0 +1,150 @@
+package scan
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"jsleaksscan/internal/config"
+	"jsleaksscan/internal/i18n"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// maxBucketPages 枚举存储桶时最多请求的列表页数 (每页通常最多 1000 个对象)
+const maxBucketPages = 1000
+
+// maxBucketPageSize 单个列表页响应体的大小上限
+const maxBucketPageSize = 32 * 1024 * 1024
+
+// bucketListing 是 S3 ListObjects (V1/V2) 返回的 XML，阿里云 OSS、GCS XML API、MinIO 等兼容存储的格式相同
+type bucketListing struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	IsTruncated           bool     `xml:"IsTruncated"`
+	NextMarker            string   `xml:"NextMarker"`
+	NextContinuationToken string   `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+	} `xml:"Contents"`
+}
+
+// bucketListingURL 将 -bucket 参数转换为列表 URL: s3://bucket/prefix 转换为
+// https://bucket.s3.amazonaws.com/?prefix=prefix，其他 URL (如 https://bucket.oss-cn-hangzhou.aliyuncs.com/?prefix=js/) 原样使用
+func bucketListingURL(raw string) (string, error) {
+	if rest, ok := strings.CutPrefix(raw, "s3://"); ok {
+		bucket, prefix, _ := strings.Cut(rest, "/")
+		if bucket == "" {
+			return "", fmt.Errorf("无效的存储桶地址 '%s'", raw)
+		}
+		listing := "https://" + bucket + ".s3.amazonaws.com/"
+		if prefix != "" {
+			listing += "?prefix=" + url.QueryEscape(prefix)
+		}
+		return listing, nil
+	}
+	target, _ := normalizeTargetURL(raw)
+	if u, err := url.Parse(target); err != nil || u.Host == "" {
+		return "", fmt.Errorf("无效的存储桶地址 '%s'", raw)
+	}
+	return target, nil
+}
+
+// listBucketObjects 分页枚举存储桶列表中的对象 (-bucket)，返回需要扫描的对象 URL
+// 只保留扩展名为脚本/文本类型 (与本地扫描相同) 且大小在 -min-body-size 和 -max-body-size 之间的对象，
+// 对象 URL 位于列表 URL 的同一主机和路径下，不会扩展到其他主机
+func listBucketObjects(client *http.Client, rawURL string, cfg *config.AppConfig) (objects []string, skipped int, err error) {
+	listingURL, err := bucketListingURL(rawURL)
+	if err != nil {
+		return nil, 0, err
+	}
+	base, err := url.Parse(listingURL)
+	if err != nil {
+		return nil, 0, err
+	}
+	query := base.Query()
+	// 对象 URL 的前缀: 列表 URL 去掉查询参数，路径以 / 结尾 (兼容路径风格 https://s3.amazonaws.com/bucket/)
+	objectBase := *base
+	objectBase.RawQuery = ""
+	objectBase.Fragment = ""
+	if !strings.HasSuffix(objectBase.Path, "/") {
+		objectBase.Path += "/"
+	}
+	objectBase.RawPath = ""
+
+	for page := 1; ; page++ {
+		pageURL := *base
+		pageURL.RawQuery = query.Encode()
+		listing, err := fetchBucketListing(client, pageURL.String(), cfg)
+		if err != nil {
+			return objects, skipped, fmt.Errorf("获取存储桶列表第 %d 页失败: %w", page, err)
+		}
+		for _, object := range listing.Contents {
+			if !isBucketTextObject(object.Key, cfg.SniffGzip) || object.Size > cfg.ScanOptions.MaxBodySize || object.Size < cfg.ScanOptions.MinBodySize {
+				skipped++
+				continue
+			}
+			objectURL := objectBase
+			objectURL.Path += object.Key
+			objects = append(objects, objectURL.String())
+		}
+		if !cfg.Quiet && cfg.Verbose {
+			i18n.Printf("存储桶列表第 %d 页: %d 个对象\n", page, len(listing.Contents))
+		}
+
+		if !listing.IsTruncated || len(listing.Contents) == 0 {
+			return objects, skipped, nil
+		}
+		if page >= maxBucketPages {
+			i18n.Printf("警告: 存储桶列表超过 %d 页，只枚举了前 %d 页的对象。\n", maxBucketPages, maxBucketPages)
+			return objects, skipped, nil
+		}
+		// V2 (list-type=2) 使用 continuation-token；V1 使用 marker，未返回 NextMarker 时以本页最后一个 Key 继续
+		if listing.NextContinuationToken != "" {
+			query.Set("continuation-token", listing.NextContinuationToken)
+		} else if listing.NextMarker != "" {
+			query.Set("marker", listing.NextMarker)
+		} else {
+			query.Set("marker", listing.Contents[len(listing.Contents)-1].Key)
+		}
+	}
+}
+
+// fetchBucketListing 请求并解析一页存储桶列表
+func fetchBucketListing(client *http.Client, listingURL string, cfg *config.AppConfig) (*bucketListing, error) {
+	req, err := http.NewRequest(http.MethodGet, listingURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	applyCustomHeaders(req, cfg.ScanOptions)
+	resp, err := doWithDNSRetry(client, req, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBucketPageSize))
+	if err != nil {
+		return nil, fmt.Errorf("读取响应体失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// 不允许公开列出的存储桶通常返回 403 AccessDenied
+		return nil, fmt.Errorf("返回状态码 %d", resp.StatusCode)
+	}
+	var listing bucketListing
+	if err := xml.Unmarshal(body, &listing); err != nil {
+		return nil, fmt.Errorf("响应不是有效的存储桶列表 XML: %w", err)
+	}
+	return &listing, nil
+}
+
+// isBucketTextObject 按扩展名判断对象是否为需要扫描的脚本或文本文件，sniffGzip 时包括 .js.gz 等压缩文件
+func isBucketTextObject(key string, sniffGzip bool) bool {
+	if strings.HasSuffix(key, "/") {
+		return false // 目录占位对象
+	}
+	if sniffGzip && isCompressedTextFile(key, jsExtensions) {
+		return true
+	}
+	return jsExtensions[strings.ToLower(path.Ext(key))]
+}
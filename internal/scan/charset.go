@@ -0,0 +1,52 @@
+package scan
+
+import (
+	"fmt"
+	"mime"
+	"regexp"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// metaCharsetPattern 匹配 HTML 中 <meta charset="..."> 或 http-equiv Content-Type 里声明的字符集
+var metaCharsetPattern = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-zA-Z0-9_:.-]+)`)
+
+// metaCharsetScanLimit 查找 <meta charset> 时只检查内容的前若干字节
+const metaCharsetScanLimit = 1024
+
+// detectCharset 从 Content-Type 的 charset 参数或 HTML 的 <meta charset> 中检测字符集，检测不到时返回空
+func detectCharset(contentType string, body []byte) string {
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		return params["charset"]
+	}
+	head := body
+	if len(head) > metaCharsetScanLimit {
+		head = head[:metaCharsetScanLimit]
+	}
+	if m := metaCharsetPattern.FindSubmatch(head); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// transcodeToUTF8 将非 UTF-8 编码 (GBK、Shift-JIS、Latin-1 等) 的内容转换为 UTF-8，返回转换后的内容和规范化的字符集名
+// 未声明字符集或已是 UTF-8 时原样返回
+func transcodeToUTF8(contentType string, body []byte) ([]byte, string, error) {
+	name := detectCharset(contentType, body)
+	if name == "" {
+		return body, "", nil
+	}
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return body, name, fmt.Errorf("不支持的字符集 '%s'", name)
+	}
+	canonical, _ := htmlindex.Name(enc)
+	if canonical == "utf-8" {
+		return body, canonical, nil
+	}
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return body, canonical, fmt.Errorf("按 %s 解码失败: %w", canonical, err)
+	}
+	return decoded, canonical, nil
+}
@@ -0,0 +1,101 @@
+package scan
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"sync"
+)
+
+// contentChunk 是大文件分块匹配时的一块: [start, end) 为该块负责的范围，实际匹配的范围向两侧各扩展 overlap 字节
+type contentChunk struct {
+	start, end int
+}
+
+// splitContent 将长度为 size 的内容按 chunkSize 划分为连续的块
+func splitContent(size, chunkSize int) []contentChunk {
+	var chunks []contentChunk
+	for start := 0; start < size; start += chunkSize {
+		chunks = append(chunks, contentChunk{start: start, end: min(start+chunkSize, size)})
+	}
+	return chunks
+}
+
+// processRegexRulesChunked 将大文件划分为多块并发匹配正则规则 (-split-large)，合并后按规则名顺序交给 emit，emit 返回 false 时停止。
+//
+// 每块实际匹配的范围向前后各扩展 overlap (-max-match-len) 字节: 长度达到 -max-match-len 的匹配本来就会被丢弃，
+// 因此跨越块边界的匹配一定完整地出现在某一块的扩展范围内；向前扩展的部分同时为 \b 等边界断言提供上下文。
+// 每个匹配只由其起始偏移所在的块负责报告，重叠范围内被相邻块重复找到的匹配被丢弃，合并后再按偏移去重。
+// 偏移换算为整个内容中的偏移，行号由调用方按完整内容计算，因此与不分块时一致
+func processRegexRulesChunked(source string, content []byte, regexRules map[string]*regexp.Regexp, bounds matchBounds, chunkSize, workers int, emit func([]ScanResult) bool) (truncated []string) {
+	names := slices.Sorted(maps.Keys(regexRules))
+	chunks := splitContent(len(content), chunkSize)
+	overlap := bounds.maxLen
+
+	type chunkResult struct {
+		results [][]ScanResult // 按规则名顺序，每条规则在该块中负责的匹配
+		more    []bool
+	}
+	perChunk := make([]chunkResult, len(chunks))
+
+	var wg sync.WaitGroup
+	queue := make(chan int, len(chunks))
+	for i := range chunks {
+		queue <- i
+	}
+	close(queue)
+	for range max(1, min(workers, len(chunks))) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range queue {
+				chunk := chunks[index]
+				from := max(0, chunk.start-overlap)
+				to := min(len(content), chunk.end+overlap)
+				result := chunkResult{results: make([][]ScanResult, len(names)), more: make([]bool, len(names))}
+				for r, name := range names {
+					matches, more := findRuleMatches(source, name, regexRules[name], content[from:to], bounds)
+					kept := matches[:0]
+					for _, match := range matches {
+						match.Offset += from
+						if match.Offset >= chunk.start && match.Offset < chunk.end {
+							kept = append(kept, match)
+						}
+					}
+					result.results[r] = kept
+					result.more[r] = more
+				}
+				perChunk[index] = result
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 按规则合并各块的结果；块按偏移顺序排列，合并后的结果也按偏移排序
+	for r, name := range names {
+		var merged []ScanResult
+		more := false
+		seen := make(map[int]bool)
+		for _, chunk := range perChunk {
+			more = more || chunk.more[r]
+			for _, match := range chunk.results[r] {
+				if seen[match.Offset] {
+					continue
+				}
+				seen[match.Offset] = true
+				merged = append(merged, match)
+			}
+		}
+		if bounds.maxPerRule > 0 && len(merged) > bounds.maxPerRule {
+			merged = merged[:bounds.maxPerRule]
+			more = true
+		}
+		if more {
+			truncated = append(truncated, name)
+		}
+		if !emit(merged) {
+			break
+		}
+	}
+	return truncated
+}
@@ -0,0 +1,134 @@
+package scan
+
+import (
+	"fmt"
+	"jsleaksscan/internal/config"
+	"jsleaksscan/internal/rules"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// collectResults 运行 process 并收集交给 emit 的全部结果
+func collectResults(process func(emit func([]ScanResult) bool)) []ScanResult {
+	var all []ScanResult
+	process(func(batch []ScanResult) bool {
+		all = append(all, batch...)
+		return true
+	})
+	return all
+}
+
+func TestProcessRegexRulesChunkedMatchesSerial(t *testing.T) {
+	regexes := map[string]*regexp.Regexp{
+		"token":    regexp.MustCompile(`tok_[a-z0-9]{12}`),
+		"word":     regexp.MustCompile(`\bsecret\b`),
+		"pem":      regexp.MustCompile(`(?s)-----BEGIN KEY-----.+?-----END KEY-----`),
+		"overlaps": regexp.MustCompile(`aa`),
+	}
+	var b strings.Builder
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&b, "line %d: tok_%012d secret notsecret aaaaa\n", i, i)
+		if i%7 == 0 {
+			b.WriteString("-----BEGIN KEY-----\nMIIBOgIBAAJBAK\n-----END KEY-----\n")
+		}
+	}
+	content := []byte(b.String())
+	bounds := matchBounds{maxLen: 64}
+
+	want := collectResults(func(emit func([]ScanResult) bool) {
+		processRegexRulesSerially("a.js", content, regexes, bounds, emit)
+	})
+	if len(want) == 0 {
+		t.Fatal("串行处理没有结果")
+	}
+	// 块大小小于匹配长度时几乎每个匹配都跨越块边界
+	for _, chunkSize := range []int{1, 7, 16, 100, 1000, len(content)} {
+		got := collectResults(func(emit func([]ScanResult) bool) {
+			processRegexRulesChunked("a.js", content, regexes, bounds, chunkSize, 4, emit)
+		})
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("块大小 %d: 分块结果 (%d 条) 与串行结果 (%d 条) 不同", chunkSize, len(got), len(want))
+		}
+	}
+}
+
+func TestProcessRegexRulesChunkedMaxPerRule(t *testing.T) {
+	regexes := map[string]*regexp.Regexp{"token": regexp.MustCompile(`tok_[0-9]{4}`)}
+	content := []byte(strings.Repeat("tok_1234 ", 100))
+	bounds := matchBounds{maxLen: 64, maxPerRule: 10}
+
+	var got []ScanResult
+	truncated := processRegexRulesChunked("a.js", content, regexes, bounds, 50, 4, func(batch []ScanResult) bool {
+		got = append(got, batch...)
+		return true
+	})
+	if len(got) != 10 || len(truncated) != 1 {
+		t.Fatalf("结果 %d 条、截断规则 %v, 期望 10 条和 [token]", len(got), truncated)
+	}
+	for i, result := range got {
+		if result.Offset != i*9 {
+			t.Errorf("第 %d 条的偏移 = %d, 期望 %d", i, result.Offset, i*9)
+		}
+	}
+}
+
+func TestProcessContentSplitLargeLineNumbers(t *testing.T) {
+	compiled := compileTestRules(t, `{
+		"token": {"pattern": "tok_[a-z0-9]{12}"},
+		"pem": {"pattern": "-----BEGIN KEY-----.+?-----END KEY-----"}
+	}`, rules.CompileOptions{Multiline: true})
+
+	// 约 2.5MB 的内容，以 1MB 分块；在块边界附近放置跨越边界的单行和多行匹配
+	const chunk = 1024 * 1024
+	var b strings.Builder
+	line := 1
+	for b.Len() < chunk*5/2 {
+		offset := b.Len()
+		switch {
+		case offset > chunk-100 && offset < chunk-50, offset > 2*chunk-100 && offset < 2*chunk-50:
+			b.WriteString("-----BEGIN KEY-----\n")
+			b.WriteString(strings.Repeat("QUJDREVGR0hJSktMTU5PUFFSU1RVVldYWVo=\n", 4))
+			b.WriteString("-----END KEY-----\n")
+			line += 6
+		case line%1000 == 0:
+			fmt.Fprintf(&b, "const key = \"tok_%012d\"; // line %d\n", line, line)
+			line++
+		default:
+			fmt.Fprintf(&b, "var filler%d = %d;\n", line, line)
+			line++
+		}
+	}
+	content := []byte(b.String())
+
+	base := config.AppConfig{MaxMatchLen: 1024, SplitSize: 1, RegexWorkers: 4, Quiet: true}
+	whole := processContent("big.js", content, compiled, &base, false, nil)
+	split := base
+	split.SplitLarge = true
+	chunked := processContent("big.js", content, compiled, &split, false, nil)
+
+	if len(whole) == 0 {
+		t.Fatal("没有结果")
+	}
+	if len(chunked) != len(whole) {
+		t.Fatalf("分块匹配得到 %d 条结果, 不分块为 %d 条", len(chunked), len(whole))
+	}
+	// 两个 PEM 块都应跨越块边界，且与不分块时一样被完整找到
+	straddling := 0
+	for i := range whole {
+		w, c := whole[i], chunked[i]
+		if c.Rule != w.Rule || c.Match != w.Match || c.Offset != w.Offset || c.Line != w.Line {
+			t.Errorf("第 %d 条结果不同: 分块 %s@%d (行 %d), 不分块 %s@%d (行 %d)", i, c.Rule, c.Offset, c.Line, w.Rule, w.Offset, w.Line)
+		}
+		if w.Line != strings.Count(string(content[:w.Offset]), "\n")+1 {
+			t.Errorf("%s 的行号 %d 与偏移 %d 不符", w.Rule, w.Line, w.Offset)
+		}
+		if w.Rule == "pem" && w.Offset/chunk != (w.Offset+len(w.Match))/chunk {
+			straddling++
+		}
+	}
+	if straddling != 2 {
+		t.Errorf("跨越块边界的 PEM 块发现数 = %d, 期望 2", straddling)
+	}
+}
@@ -0,0 +1,57 @@
+package scan
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// cleanRecorder 将扫描成功但没有任何发现的来源逐行追加写入文件 (-record-clean)，
+// 用于覆盖率审计：确认某个文件或 URL 确实被扫描过，而不是被跳过。可被多个 goroutine 并发使用
+type cleanRecorder struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	writer *bufio.Writer
+	count  int
+	err    error
+}
+
+func newCleanRecorder(path string) (*cleanRecorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开无发现来源记录文件 '%s' 失败: %w", path, err)
+	}
+	return &cleanRecorder{path: path, file: file, writer: bufio.NewWriter(file)}, nil
+}
+
+// record 记录一个没有发现的来源，写入失败时保留第一个错误，由 Close 返回
+func (c *cleanRecorder) record(source string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	if _, err := fmt.Fprintln(c.writer, source); err != nil {
+		c.err = err
+		return
+	}
+	c.count++
+}
+
+// Close 刷新缓冲并关闭文件
+func (c *cleanRecorder) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.writer.Flush(); err != nil && c.err == nil {
+		c.err = err
+	}
+	if err := c.file.Close(); err != nil && c.err == nil {
+		c.err = err
+	}
+	if c.err != nil {
+		return fmt.Errorf("写入无发现来源记录文件 '%s' 失败: %w", c.path, c.err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package scan
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"jsleaksscan/internal/rules"
+	"regexp"
+	"strings"
+)
+
+// cloudEnricher 从云服务商密钥的匹配内容中解析结构化的元信息 (账号、项目等)，无法解析时返回 nil
+type cloudEnricher func(match string) map[string]string
+
+// cloudEnrichers 按规则标签选择的解析器: 规则带有对应标签 (见规则的 "tags" 字段) 时，发现附加解析出的元信息
+var cloudEnrichers = map[string]cloudEnricher{
+	"aws":   enrichAWSKey,
+	"gcp":   enrichGCPServiceAccount,
+	"azure": enrichAzureConnectionString,
+}
+
+// enrichCloudMetadata 依次调用规则标签对应的解析器，合并解析出的元信息；没有元信息时返回 nil
+func enrichCloudMetadata(match string, meta rules.RuleMeta) map[string]string {
+	var metadata map[string]string
+	for _, tag := range meta.Tags {
+		enrich, ok := cloudEnrichers[tag]
+		if !ok {
+			continue
+		}
+		for key, value := range enrich(match) {
+			if metadata == nil {
+				metadata = make(map[string]string)
+			}
+			metadata[key] = value
+		}
+	}
+	return metadata
+}
+
+// awsKeyTypes 是 AWS 唯一标识符前缀对应的类型
+var awsKeyTypes = map[string]string{
+	"AKIA": "access_key",               // IAM 用户的长期访问密钥
+	"ASIA": "temporary_access_key",     // STS 颁发的临时访问密钥
+	"ABIA": "sts_service_bearer_token", // STS 服务持有者令牌
+	"ACCA": "context_credential",       // 特定上下文的凭证
+	"AGPA": "group",
+	"AIDA": "user",
+	"AIPA": "instance_profile",
+	"AKPA": "public_key",
+	"ANPA": "managed_policy",
+	"ANVA": "managed_policy_version",
+	"APKA": "public_key",
+	"AROA": "role",
+	"ASCA": "certificate",
+}
+
+var awsKeyIDPattern = regexp.MustCompile(`\b(A[A-Z]{3})([A-Z2-7]{16})\b`)
+
+// enrichAWSKey 解析匹配内容中的 AWS 访问密钥 ID: 前缀表示的密钥类型，以及编码在密钥 ID 中的 12 位账号 ID
+// (2019 年后创建的访问密钥 ID 中第 5 至 16 个字符以 base32 编码了账号 ID)
+func enrichAWSKey(match string) map[string]string {
+	m := awsKeyIDPattern.FindStringSubmatch(match)
+	if m == nil {
+		return nil
+	}
+	keyType, ok := awsKeyTypes[m[1]]
+	if !ok {
+		return nil
+	}
+	metadata := map[string]string{"aws_key_id": m[0], "aws_key_prefix": m[1], "aws_key_type": keyType}
+	if decoded, err := base32.StdEncoding.DecodeString(m[2]); err == nil && len(decoded) >= 6 {
+		z := binary.BigEndian.Uint64(append([]byte{0, 0}, decoded[:6]...))
+		metadata["aws_account_id"] = fmt.Sprintf("%012d", (z&0x7fffffffff80)>>7)
+	}
+	return metadata
+}
+
+var gcpServiceAccountEmail = regexp.MustCompile(`[a-z0-9][a-z0-9-]*@([a-z][a-z0-9-]{4,28}[a-z0-9])\.iam\.gserviceaccount\.com`)
+
+// enrichGCPServiceAccount 解析 GCP 服务账号密钥: 匹配内容为 (部分) 服务账号 JSON 时读取 project_id、client_email 和 private_key_id，
+// 否则从服务账号邮箱 (name@project.iam.gserviceaccount.com) 中得到项目 ID
+func enrichGCPServiceAccount(match string) map[string]string {
+	metadata := make(map[string]string)
+	var account struct {
+		ProjectID    string `json:"project_id"`
+		ClientEmail  string `json:"client_email"`
+		PrivateKeyID string `json:"private_key_id"`
+	}
+	// 匹配内容可能是完整的 JSON 对象，也可能只是其中的若干字段
+	text := strings.TrimSpace(match)
+	if !strings.HasPrefix(text, "{") {
+		text = "{" + strings.TrimSuffix(text, ",") + "}"
+	}
+	if json.Unmarshal([]byte(text), &account) == nil {
+		for key, value := range map[string]string{"gcp_project_id": account.ProjectID, "gcp_client_email": account.ClientEmail, "gcp_private_key_id": account.PrivateKeyID} {
+			if value != "" {
+				metadata[key] = value
+			}
+		}
+	}
+	if m := gcpServiceAccountEmail.FindStringSubmatch(match); m != nil {
+		if metadata["gcp_client_email"] == "" {
+			metadata["gcp_client_email"] = m[0]
+		}
+		if metadata["gcp_project_id"] == "" {
+			metadata["gcp_project_id"] = m[1]
+		}
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
+
+// enrichAzureConnectionString 解析 Azure 存储连接字符串 (Key=Value;...) 中的存储账号名和终结点后缀
+func enrichAzureConnectionString(match string) map[string]string {
+	metadata := make(map[string]string)
+	for _, part := range strings.Split(match, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || value == "" {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "accountname":
+			metadata["azure_account_name"] = value
+		case "endpointsuffix":
+			metadata["azure_endpoint_suffix"] = value
+		case "sharedaccesssignature":
+			metadata["azure_credential_type"] = "sas"
+		case "accountkey":
+			metadata["azure_credential_type"] = "account_key"
+		}
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
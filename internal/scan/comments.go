@@ -0,0 +1,139 @@
+package scan
+
+import (
+	"bytes"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// commentSyntax 描述一种语言的注释和字符串语法，用于 -strip-comments
+type commentSyntax struct {
+	lineComments []string // 行注释起始符，例如 "//"、"#"
+	blockStart   string   // 块注释起始符，例如 "/*"，为空表示不支持块注释
+	blockEnd     string
+	quotes       string // 字符串定界符，字符串内的注释符号不会被当作注释
+	tripleQuotes bool   // 支持 Python 的三引号字符串
+	afterSpace   bool   // 行注释符只在行首或空白之后开始注释 (YAML、Shell、INI)，如 P@ss#word1 中的 # 不是注释
+}
+
+var (
+	cStyleComments     = commentSyntax{lineComments: []string{"//"}, blockStart: "/*", blockEnd: "*/", quotes: "\"'`"}
+	phpComments        = commentSyntax{lineComments: []string{"//", "#"}, blockStart: "/*", blockEnd: "*/", quotes: "\"'"}
+	cssComments        = commentSyntax{blockStart: "/*", blockEnd: "*/", quotes: "\"'"}
+	hashComments       = commentSyntax{lineComments: []string{"#"}, quotes: "\"'", afterSpace: true}
+	pythonComments     = commentSyntax{lineComments: []string{"#"}, quotes: "\"'", tripleQuotes: true}
+	iniComments        = commentSyntax{lineComments: []string{"#", ";"}, quotes: "\"", afterSpace: true}
+	markupComments     = commentSyntax{blockStart: "<!--", blockEnd: "-->"}
+	commentSyntaxByExt = map[string]commentSyntax{
+		".js": cStyleComments, ".jsx": cStyleComments, ".mjs": cStyleComments, ".cjs": cStyleComments,
+		".ts": cStyleComments, ".tsx": cStyleComments, ".go": cStyleComments, ".java": cStyleComments,
+		".cs": cStyleComments, ".c": cStyleComments, ".h": cStyleComments, ".cpp": cStyleComments,
+		".kt": cStyleComments, ".swift": cStyleComments, ".rs": cStyleComments,
+		".php": phpComments, ".css": cssComments,
+		".py": pythonComments, ".sh": hashComments, ".rb": hashComments,
+		".yaml": hashComments, ".yml": hashComments, ".conf": hashComments,
+		".ini": iniComments, ".cfg": iniComments,
+		".html": markupComments, ".htm": markupComments, ".xml": markupComments,
+	}
+)
+
+// sourceExt 返回来源的扩展名 (小写)，URL 来源取路径部分的扩展名，忽略查询参数
+func sourceExt(source string) string {
+	if u, err := url.Parse(source); err == nil && u.Scheme != "" && u.Host != "" {
+		return strings.ToLower(path.Ext(u.Path))
+	}
+	return strings.ToLower(filepath.Ext(source))
+}
+
+// stripComments 按来源扩展名对应的语言将注释替换为空格 (保留换行)，返回处理后的内容和移除的注释数量
+// 替换而非删除注释，使匹配的偏移和行号与原始内容保持一致；不支持的语言原样返回
+func stripComments(source string, content []byte) ([]byte, int) {
+	syntax, ok := commentSyntaxByExt[sourceExt(source)]
+	if !ok {
+		return content, 0
+	}
+
+	out := bytes.Clone(content)
+	stripped := 0
+	blank := func(start, end int) {
+		for j := start; j < end; j++ {
+			if out[j] != '\n' && out[j] != '\r' {
+				out[j] = ' '
+			}
+		}
+		stripped++
+	}
+
+	n := len(content)
+	for i := 0; i < n; {
+		rest := content[i:]
+		switch {
+		case syntax.blockStart != "" && bytes.HasPrefix(rest, []byte(syntax.blockStart)):
+			end := bytes.Index(content[i+len(syntax.blockStart):], []byte(syntax.blockEnd))
+			if end < 0 {
+				end = n
+			} else {
+				end = i + len(syntax.blockStart) + end + len(syntax.blockEnd)
+			}
+			blank(i, end)
+			i = end
+		case hasAnyPrefix(rest, syntax.lineComments) && (!syntax.afterSpace || i == 0 || isSpaceByte(content[i-1])):
+			end := bytes.IndexByte(rest, '\n')
+			if end < 0 {
+				end = n
+			} else {
+				end += i
+			}
+			blank(i, end)
+			i = end
+		case strings.IndexByte(syntax.quotes, content[i]) >= 0:
+			i = skipString(content, i, syntax.tripleQuotes)
+		default:
+			i++
+		}
+	}
+	return out, stripped
+}
+
+// isSpaceByte 判断字节是否为空白字符 (含换行)
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func hasAnyPrefix(b []byte, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if bytes.HasPrefix(b, []byte(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipString 跳过从 start 开始的字符串字面量，返回字符串结束后的位置
+// 普通引号字符串在换行处结束 (避免未闭合的引号吞掉后续内容)，反引号和三引号字符串可以跨行
+func skipString(content []byte, start int, tripleQuotes bool) int {
+	quote := content[start]
+	if tripleQuotes && bytes.HasPrefix(content[start:], []byte{quote, quote, quote}) {
+		end := bytes.Index(content[start+3:], []byte{quote, quote, quote})
+		if end < 0 {
+			return len(content)
+		}
+		return start + 3 + end + 3
+	}
+	multiline := quote == '`'
+	for i := start + 1; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++ // 跳过转义字符
+		case quote:
+			return i + 1
+		case '\n':
+			if !multiline {
+				return i
+			}
+		}
+	}
+	return len(content)
+}
@@ -0,0 +1,29 @@
+package scan
+
+import "testing"
+
+func TestStripComments(t *testing.T) {
+	tests := []struct {
+		source  string
+		content string
+		want    string
+	}{
+		{"c.yaml", "password: P@ss#word1\n", "password: P@ss#word1\n"},
+		{"c.yaml", "# old: AKIA\nkey: v # note\n", "           \nkey: v       \n"},
+		{"c.yaml", "key: \"a #b\" #c\n", "key: \"a #b\"   \n"},
+		{"run.sh", "export TOKEN=abc#def\n\t# comment\n", "export TOKEN=abc#def\n\t         \n"},
+		{"a.ini", "pass=x;y#z\n; comment\nk=v ;c\n", "pass=x;y#z\n         \nk=v   \n"},
+		{"a.py", "key = 1#c\n", "key = 1  \n"},
+		{"a.js", "var a = 'x//y'; // c\n/* b\n */var b;\n", "var a = 'x//y';     \n    \n   var b;\n"},
+		{"a.txt", "# kept\n", "# kept\n"},
+	}
+	for _, tt := range tests {
+		got, _ := stripComments(tt.source, []byte(tt.content))
+		if string(got) != tt.want {
+			t.Errorf("stripComments(%s, %q) = %q, 期望 %q", tt.source, tt.content, got, tt.want)
+		}
+		if len(got) != len(tt.content) {
+			t.Errorf("stripComments(%s) 改变了内容长度", tt.source)
+		}
+	}
+}
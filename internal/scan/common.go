@@ -3,29 +3,74 @@ package scan
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"jsleaksscan/internal/config"
+	"jsleaksscan/internal/httpclient"
+	"jsleaksscan/internal/i18n"
 	"jsleaksscan/internal/rules" // 导入规则包
 	"jsleaksscan/internal/utils" // 导入工具包
+	"maps"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+	"unicode"
 )
 
 // ScanResult 存储单次扫描发现的结果
 type ScanResult struct {
-	Source string // 文件路径或 URL
-	Rule   string // 命中的规则名
-	Match  string // 匹配到的具体内容
+	Source   string    // 文件路径或 URL
+	Rule     string    // 命中的规则名
+	Pattern  string    // 产生该匹配的正则表达式或字面量 (外部匹配程序的结果为空)
+	Match    string    // 匹配到的具体内容
+	Severity string    // 规则的严重级别，未设置时为空
+	Offset   int       // 匹配在内容中的字节偏移
+	Line     int       // 匹配所在行号 (从 1 开始)
+	EndLine  int       // -merge-lines 合并多行匹配后的结束行号，未合并时为 0
+	FoundAt  time.Time // 发现时间 (UTC)
+	RunID    string    // 产生该发现的扫描运行 ID
+	Status   int       // HTTP 响应状态码 (仅 URL 扫描)
+	FinalURL string    // 跟随重定向后的最终 URL (仅 URL 扫描)
+	// Fingerprint 是由规范化的来源、规则名和匹配内容计算的稳定指纹，不受行号变化影响 (见 findingFingerprint)
+	Fingerprint string
+	// Encoding 为 Match 的编码 (-binary-match 的 hex 或 base64)，匹配内容为普通文本时为空
+	Encoding string
+	// JWT 为 -jwt 时从匹配内容中的 JWT 解码出的信息，匹配内容不包含 JWT 时为 nil
+	JWT *JWTClaims
+	// Confidence 是由规则声明的置信度和匹配内容的熵等信号计算的综合置信度 (0-100，见 scoreConfidence)
+	Confidence int
+	// Metadata 是按规则标签 (aws/gcp/azure) 从云服务商密钥中解析出的元信息 (见 enrichCloudMetadata)，没有时为 nil
+	Metadata map[string]string
+	// Tier 是分层扫描 (-deep) 时产生该发现的层 (fast 或 deep)，未分层时为空
+	Tier string
+	// Key 是 -key-context 时匹配所赋给的变量、对象键或函数名 (见 enclosingKey)，找不到或不是 JS/JSON 来源时为空
+	Key string
 }
 
 // WriteResultsToFile 将结果批量写入单个文件
 // 使用锁确保并发写入安全
 var fileWriteMutex sync.Mutex
 
-func WriteResultsToFile(filename string, results []ScanResult) error {
+// FindingsOutput 是 -findings-only 模式下打印发现的目标 (由 main 设置为原始标准输出)
+var FindingsOutput io.Writer = os.Stdout
+
+// verbose 为 true 时，URL 扫描的结果会附加响应状态码和最终 URL；tmpl 不为 nil 时改用模板格式化 (见 formatResults)
+// 返回写入的字节数
+func WriteResultsToFile(filename string, results []ScanResult, verbose bool, tmpl *template.Template) (int, error) {
 	if len(results) == 0 {
-		return nil // 没有结果，无需写入
+		return 0, nil // 没有结果，无需写入
 	}
 
 	fileWriteMutex.Lock()
@@ -33,131 +78,472 @@ func WriteResultsToFile(filename string, results []ScanResult) error {
 
 	// O_APPEND 模式打开文件，允许多个 goroutine 安全地追加写入
 	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if os.IsNotExist(err) {
+		// 输出子目录 (例如分片目录) 在首次写入时才创建
+		if mkErr := os.MkdirAll(filepath.Dir(filename), 0755); mkErr != nil {
+			return 0, fmt.Errorf("创建输出目录 '%s' 失败: %w", filepath.Dir(filename), mkErr)
+		}
+		file, err = os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	}
 	if err != nil {
-		return fmt.Errorf("打开输出文件 '%s' 失败: %w", filename, err)
+		return 0, fmt.Errorf("打开输出文件 '%s' 失败: %w", filename, err)
 	}
 	defer file.Close()
 
 	// 预估缓冲区大小
 	estimatedSize := 0
 	for _, result := range results {
-		estimatedSize += len(result.Source) + len(result.Rule) + len(result.Match) + 10 // 估算额外字符
+		estimatedSize += len(result.Source) + len(result.Rule) + len(result.Match) + len(result.FinalURL) + 10 // 估算额外字符
 	}
 	buf := bytes.NewBuffer(make([]byte, 0, estimatedSize))
 
 	// 格式化结果并写入缓冲区
-	for _, result := range results {
-		// 格式：[来源] 规则名: 匹配内容
-		fmt.Fprintf(buf, "[%s] %s: %s\n", result.Source, result.Rule, result.Match)
+	if err := formatResults(buf, results, verbose, tmpl); err != nil {
+		return 0, err
 	}
 
 	// 使用带缓冲的写入器提高性能
 	writer := bufio.NewWriterSize(file, 64*1024) // 64KB buffer
 	if _, err := writer.Write(buf.Bytes()); err != nil {
 		_ = writer.Flush() // 尝试刷新缓冲区
-		return fmt.Errorf("写入结果到 '%s' 失败: %w", filename, err)
+		return 0, fmt.Errorf("写入结果到 '%s' 失败: %w", filename, err)
 	}
 
 	// 确保所有缓冲数据写入文件
 	if err := writer.Flush(); err != nil {
-		return fmt.Errorf("刷新缓冲区到 '%s' 失败: %w", filename, err)
+		return 0, fmt.Errorf("刷新缓冲区到 '%s' 失败: %w", filename, err)
 	}
 
+	return buf.Len(), nil
+}
+
+// formatResultLine 按文本格式写入一条结果
+// 格式：[来源] 规则名: 匹配内容
+func formatResultLine(buf *bytes.Buffer, result ScanResult, verbose bool) {
+	fmt.Fprintf(buf, "[%s] %s: %s", result.Source, result.Rule, result.Match)
+	if result.Encoding != "" {
+		// 匹配内容含二进制字节，已按 -binary-match 编码
+		fmt.Fprintf(buf, " [%s]", result.Encoding)
+	}
+	if result.JWT != nil {
+		// 解码出的 JWT 信息 (-jwt)
+		fmt.Fprintf(buf, " [JWT %s]", result.JWT)
+	}
+	if result.Key != "" {
+		// 匹配所属的键名 (-key-context)
+		fmt.Fprintf(buf, " [key: %s]", result.Key)
+	}
+	if result.Tier != "" {
+		// 分层扫描 (-deep) 时产生该发现的层
+		fmt.Fprintf(buf, " [tier: %s]", result.Tier)
+	}
+	if verbose && result.Status != 0 {
+		// 详细模式附加：(状态码 -> 最终 URL)
+		fmt.Fprintf(buf, " (%d -> %s)", result.Status, result.FinalURL)
+	} else if result.Status != 0 && (result.Status < 200 || result.Status >= 300) {
+		// 来自非 2xx 响应 (-accept-status、-scan-status) 的发现总是标注状态码
+		fmt.Fprintf(buf, " [status: %d]", result.Status)
+	}
+	if verbose {
+		// 详细模式附加：综合置信度
+		fmt.Fprintf(buf, " [confidence: %d]", result.Confidence)
+	}
+	if verbose && result.Pattern != "" {
+		// 详细模式附加：产生该匹配的模式
+		fmt.Fprintf(buf, " [pattern: %s]", result.Pattern)
+	}
+	buf.WriteByte('\n')
+}
+
+// sourceTemplateName 是模板中按来源输出的子模板名，定义后对每个来源执行一次 (数据为 templateSource)
+const sourceTemplateName = "source"
+
+// templateSource 是 "source" 子模板的数据: 一个来源及其全部发现
+type templateSource struct {
+	Source  string
+	Results []ScanResult
+}
+
+// formatResults 将结果格式化写入 buf。tmpl 为 nil 时使用内置文本格式 (formatResultLine)；
+// 否则模板定义了 "source" 子模板时按来源分组执行该子模板，未定义时对每条结果执行一次模板 (数据为 ScanResult)。
+// 模板输出原样写入，换行由模板自身控制
+func formatResults(buf *bytes.Buffer, results []ScanResult, verbose bool, tmpl *template.Template) error {
+	if tmpl == nil {
+		for _, result := range results {
+			formatResultLine(buf, result, verbose)
+		}
+		return nil
+	}
+	if source := tmpl.Lookup(sourceTemplateName); source != nil {
+		for start := 0; start < len(results); {
+			end := start + 1
+			for end < len(results) && results[end].Source == results[start].Source {
+				end++
+			}
+			if err := source.Execute(buf, templateSource{Source: results[start].Source, Results: results[start:end]}); err != nil {
+				return fmt.Errorf("执行结果模板失败: %w", err)
+			}
+			start = end
+		}
+		return nil
+	}
+	for _, result := range results {
+		if err := tmpl.Execute(buf, result); err != nil {
+			return fmt.Errorf("执行结果模板失败: %w", err)
+		}
+	}
 	return nil
 }
 
+// checkResultTemplate 用一条示例结果试运行模板，在扫描开始前发现引用了不存在字段等执行错误
+func checkResultTemplate(tmpl *template.Template) error {
+	sample := []ScanResult{{Source: "example.js", Rule: "rule", Match: "match", Line: 1, FoundAt: time.Now().UTC()}}
+	return formatResults(&bytes.Buffer{}, sample, false, tmpl)
+}
+
+// resultSink 接收 processContent 在查找过程中陆续产生的结果 (-stream-findings)
+// 同一来源的结果在调用 processContent 的 goroutine 中依次交给 sink，不同来源可能并发调用
+type resultSink func(results []ScanResult)
+
 // processContent 对给定的内容（字节切片）应用规则集
 // sourceIdentifier 用于结果输出，可以是文件路径或 URL
+// 整个内容作为一个整体匹配，因此跨行的正则 (配合 (?s) 或 -multiline) 可以正常工作
+// sink 不为 nil 时，每个匹配阶段 (字面量规则、每条正则规则、外部匹配程序等) 完成后立即将其结果交给 sink，
+// 而不是等整个来源处理完；返回值总是包含全部结果
 // Returns a slice of ScanResult
-func processContent(sourceIdentifier string, content []byte, compiledRules *rules.CompiledRules, useConcurrency bool) []ScanResult {
+func processContent(sourceIdentifier string, content []byte, compiledRules *rules.CompiledRules, cfg *config.AppConfig, useConcurrency bool, sink resultSink) []ScanResult {
 	var combinedResults []ScanResult
 
-	// 1. 处理字面量规则
-	literalMatches := processLiteralRules(sourceIdentifier, content, compiledRules.Literal)
-	combinedResults = append(combinedResults, literalMatches...)
+	// 0. 按语言移除注释 (-strip-comments)，注释被替换为空格，偏移和行号保持不变
+	if cfg.StripComments {
+		var stripped int
+		content, stripped = stripComments(sourceIdentifier, content)
+		if stripped > 0 && !cfg.Quiet && cfg.Verbose {
+			i18n.Printf("已移除 '%s' 中的 %d 处注释，注释中的内容不会被匹配。\n", sourceIdentifier, stripped)
+		}
+	}
+
+	// 0.1 合并 JS/TS 源码中的字符串拼接 (-join-strings)，使被拆开的密钥可以被匹配；内容长度和换行数不变
+	if cfg.JoinStrings {
+		var joined int
+		content, joined = joinStrings(sourceIdentifier, content)
+		if joined > 0 && !cfg.Quiet && cfg.Verbose {
+			i18n.Printf("已合并 '%s' 中的 %d 处字符串拼接。\n", sourceIdentifier, joined)
+		}
+	}
+
+	// 每一批匹配经过校验和后处理后加入结果，并立即交给 sink (如果有)
+	// -first-only 时得到第一个发现后即停止扫描该来源，emit 返回 false 通知匹配器不再继续
+	// 分层扫描 (-deep) 时 tier 为当前运行的层，发现标注该层
+	lines := sync.OnceValue(func() lineIndex { return newLineIndex(content) })
+	done := false
+	tiers := compiledRules.Tiers
+	if tiers == nil {
+		tiers = []rules.RuleTier{{Regex: compiledRules.Regex, Literal: compiledRules.Literal}}
+	}
+	tier := tiers[0].Name
+	// 查找 JS/JSON 来源中匹配所属的键名 (-key-context)
+	keyContext := cfg.KeyContext && isKeyContextSource(sourceIdentifier)
+	emit := func(batch []ScanResult) bool {
+		if done {
+			return false
+		}
+		batch = finalizeResults(batch, compiledRules, cfg, lines)
+		if len(batch) == 0 {
+			return true
+		}
+		for i := range batch {
+			batch[i].Tier = tier
+			if keyContext {
+				batch[i].Key = enclosingKey(content, batch[i].Offset)
+			}
+		}
+		if cfg.FirstOnly {
+			batch = batch[:1]
+			done = true
+		}
+		if sink != nil {
+			sink(batch)
+		}
+		combinedResults = append(combinedResults, batch...)
+		return !done
+	}
+
+	// 1-2. 处理快速层 (未分层时为全部规则) 的字面量和正则表达式规则
+	if !processRuleTier(sourceIdentifier, content, tiers[0], cfg, useConcurrency, emit) {
+		return combinedResults
+	}
 
-	// 2. 处理正则表达式规则
-	var regexMatches []ScanResult
+	// 3. 运行外部匹配程序 (-matcher)，其结果与内置规则的结果合并
+	if cfg.Matcher != "" {
+		externalMatches, err := runExternalMatcher(cfg.Matcher, sourceIdentifier, content)
+		if err != nil {
+			i18n.Printf("警告: %v\n", err)
+		}
+		if !emit(externalMatches) {
+			return combinedResults
+		}
+	}
+
+	// 4. 提取 API 端点、URL 和路径 (-endpoints)，作为 endpoint 规则的发现输出
+	if cfg.Endpoints && !emit(extractEndpoints(sourceIdentifier, content, newMatchBounds(cfg))) {
+		return combinedResults
+	}
+
+	// 5. 检测并解码 JWT (-jwt)，已包含在规则匹配中的令牌不再重复报告
+	if cfg.DecodeJWT && !emit(detectJWTs(sourceIdentifier, content, newMatchBounds(cfg), combinedResults, time.Now().UTC())) {
+		return combinedResults
+	}
+
+	// 6. 运行深度层的规则 (-deep)，其发现在快速层和内置检测的发现之后输出
+	for _, next := range tiers[1:] {
+		tier = next.Name
+		if !processRuleTier(sourceIdentifier, content, next, cfg, useConcurrency, emit) {
+			return combinedResults
+		}
+	}
+
+	// 7. 解码内嵌的 data: URI (-data-uris)，将载荷作为嵌套来源扫描；嵌套载荷中的 data: URI 不再展开
+	if cfg.DataURIs {
+		nestedCfg := *cfg
+		nestedCfg.DataURIs = false
+		for i, uri := range extractDataURIs(content) {
+			nestedSource := dataURISource(sourceIdentifier, i+1)
+			if !cfg.Quiet && cfg.Verbose {
+				i18n.Printf("解码 '%s' 中的 data: URI (%s, %d 字节)，作为 '%s' 扫描。\n", sourceIdentifier, uri.mediaType, len(uri.payload), nestedSource)
+			}
+			nested := processContent(nestedSource, uri.payload, compiledRules, &nestedCfg, false, sink)
+			combinedResults = append(combinedResults, nested...)
+			if cfg.FirstOnly && len(nested) > 0 {
+				break
+			}
+		}
+	}
+
+	return combinedResults
+}
+
+// processRuleTier 依次处理一层规则中的字面量规则和正则表达式规则，每条规则查找完成后即交给 emit；
+// emit 返回 false (-first-only 已得到发现) 时返回 false
+func processRuleTier(sourceIdentifier string, content []byte, tier rules.RuleTier, cfg *config.AppConfig, useConcurrency bool, emit func([]ScanResult) bool) bool {
+	if !emit(processLiteralRules(sourceIdentifier, content, tier.Literal)) {
+		return false
+	}
+
+	var truncatedRules []string
+	stopped := false
+	tracked := func(batch []ScanResult) bool {
+		if !emit(batch) {
+			stopped = true
+			return false
+		}
+		return true
+	}
 	// 根据内容大小和规则数量决定是否并发处理正则
-	shouldBeConcurrent := useConcurrency && len(content) > 1024*1024 && len(compiledRules.Regex) > 5
-	if shouldBeConcurrent {
-		regexMatches = processRegexRulesConcurrently(sourceIdentifier, content, compiledRules.Regex)
+	shouldBeConcurrent := useConcurrency && len(content) > 1024*1024 && len(tier.Regex) > 5
+	// 超过 -split-size 的内容划分为重叠的块并发匹配 (-split-large)，单个超大文件不再只占用一个 CPU 核心
+	splitSize := cfg.SplitSize * 1024 * 1024
+	if cfg.SplitLarge && len(content) > splitSize {
+		if !cfg.Quiet && cfg.Verbose {
+			i18n.Printf("'%s' 大于 %dMB，分为 %d 块并发匹配。\n", sourceIdentifier, cfg.SplitSize, len(splitContent(len(content), splitSize)))
+		}
+		truncatedRules = processRegexRulesChunked(sourceIdentifier, content, tier.Regex, newMatchBounds(cfg), splitSize, cfg.RegexWorkers, tracked)
+	} else if shouldBeConcurrent {
+		truncatedRules = processRegexRulesConcurrently(sourceIdentifier, content, tier.Regex, newMatchBounds(cfg), cfg.RegexWorkers, tracked)
 	} else {
-		regexMatches = processRegexRulesSerially(sourceIdentifier, content, compiledRules.Regex)
+		truncatedRules = processRegexRulesSerially(sourceIdentifier, content, tier.Regex, newMatchBounds(cfg), tracked)
+	}
+	if stopped {
+		return false
+	}
+	if !cfg.Quiet {
+		for _, ruleName := range truncatedRules {
+			i18n.Printf("提示: 规则 '%s' 在 '%s' 中的匹配超过 %d 处，只记录了前 %d 处 (+更多，见 -max-matches-per-rule)。\n", ruleName, sourceIdentifier, cfg.MaxMatchesPerRule, cfg.MaxMatchesPerRule)
+		}
+	}
+	return true
+}
+
+// finalizeResults 对一批匹配做二次校验和后处理，并附加规则元信息、行号、发现时间和运行 ID；
+// 含二进制字节的匹配内容在计算指纹后按 -binary-match 编码
+func finalizeResults(results []ScanResult, compiledRules *rules.CompiledRules, cfg *config.AppConfig, lines func() lineIndex) []ScanResult {
+	// 按规则的 confirm/deny 正则过滤匹配内容，
+	// 再按规则的 transform 对匹配内容做后处理 (在 confirm/deny 校验之后、计算指纹之前)，处理后为空的匹配被丢弃
+	kept := results[:0]
+	for _, result := range results {
+		if filter, ok := compiledRules.Filters[result.Rule]; ok && !filter.Allows([]byte(result.Match)) {
+			continue
+		}
+		if transforms, ok := compiledRules.Transforms[result.Rule]; ok {
+			result.Match = rules.ApplyTransforms(transforms, result.Match)
+			if result.Match == "" {
+				continue
+			}
+		}
+		kept = append(kept, result)
+	}
+	if len(kept) == 0 {
+		return nil
 	}
-	combinedResults = append(combinedResults, regexMatches...)
 
-	return combinedResults
+	// 附加规则元信息 (严重级别按 -severity-paths 调整)、行号、发现时间和运行 ID；
+	// 规则没有设置严重级别时保留匹配器给出的级别 (如 -jwt 的内置检测)
+	foundAt := time.Now().UTC()
+	index := lines()
+	for i := range kept {
+		severity := compiledRules.Meta[kept[i].Rule].Severity
+		if severity == "" {
+			severity = kept[i].Severity
+		}
+		kept[i].Severity = rules.AdjustSeverity(compiledRules.PathSeverities, kept[i].Source, kept[i].Rule, severity)
+		kept[i].Line = index.lineAt(kept[i].Offset)
+		kept[i].FoundAt = foundAt
+		kept[i].RunID = cfg.RunID
+		// 解码规则匹配内容中的 JWT (-jwt)
+		if cfg.DecodeJWT && kept[i].JWT == nil {
+			annotateJWT(&kept[i], foundAt)
+		}
+	}
+	// 合并同一规则在相邻行上的匹配 (-merge-lines)，指纹按合并后的内容计算
+	if cfg.MergeLines {
+		kept = mergeAdjacentLines(kept)
+	}
+	// 计算综合置信度 (在编码二进制匹配内容之前，按原始内容计算熵)，低于 -min-confidence 的发现被丢弃；
+	// 解析云服务商密钥的元信息 (按合并后的匹配内容)
+	confident := kept[:0]
+	for _, result := range kept {
+		result.Metadata = enrichCloudMetadata(result.Match, compiledRules.Meta[result.Rule])
+		result.Confidence = scoreConfidence(result, compiledRules)
+		if result.Confidence >= cfg.MinConfidence {
+			confident = append(confident, result)
+		}
+	}
+	kept = confident
+	for i := range kept {
+		kept[i].Fingerprint = findingFingerprint(kept[i].Source, kept[i].Rule, kept[i].Match)
+		kept[i].Match, kept[i].Encoding = encodeBinaryMatch(kept[i].Match, cfg.BinaryMatch)
+	}
+	return kept
 }
 
-// processLiteralRules 处理字面量规则
+// processLiteralRules 按规则名顺序处理字面量规则，使多次扫描的结果顺序一致
 func processLiteralRules(source string, content []byte, literalRules map[string]string) []ScanResult {
 	var results []ScanResult
 	patternBytes := utils.BufferPool.Get().(*bytes.Buffer)
 	patternBytes.Reset()
 	defer utils.BufferPool.Put(patternBytes)
 
-	for ruleName, pattern := range literalRules {
+	for _, ruleName := range slices.Sorted(maps.Keys(literalRules)) {
+		pattern := literalRules[ruleName]
 		patternBytes.Reset()
 		patternBytes.WriteString(pattern) // 将 pattern 转换为 []byte
-		if bytes.Contains(content, patternBytes.Bytes()) {
+		if offset := bytes.Index(content, patternBytes.Bytes()); offset >= 0 {
 			results = append(results, ScanResult{
-				Source: source,
-				Rule:   ruleName,
-				Match:  pattern, // 字面量匹配，直接用 pattern 作为匹配内容
+				Source:  source,
+				Rule:    ruleName,
+				Pattern: pattern,
+				Match:   pattern, // 字面量匹配，直接用 pattern 作为匹配内容
+				Offset:  offset,
 			})
 		}
 	}
 	return results
 }
 
-// processRegexRulesSerially 串行处理正则表达式规则
-func processRegexRulesSerially(source string, content []byte, regexRules map[string]*regexp.Regexp) []ScanResult {
-	var results []ScanResult
+// processRegexRulesSerially 按规则名顺序串行处理正则表达式规则，每条规则的结果查找完成后交给 emit，emit 返回 false 时停止
+// 不满足 bounds 长度限制的匹配会被丢弃；truncated 为匹配数超过 -max-matches-per-rule 的规则
+func processRegexRulesSerially(source string, content []byte, regexRules map[string]*regexp.Regexp, bounds matchBounds, emit func([]ScanResult) bool) (truncated []string) {
 	buf := utils.BufferPool.Get().(*bytes.Buffer)
 	buf.Reset()
 	defer utils.BufferPool.Put(buf)
 
-	for ruleName, reg := range regexRules {
-		// FindAllIndex 效率可能更高，因为它避免了子切片的创建
-		// -1 表示查找所有匹配项
-		matches := reg.FindAll(content, -1)
-		for _, match := range matches {
-			// 检查匹配是否为空或过长 (可选，防止意外匹配)
-			if len(match) > 0 && len(match) < 1024 { // 示例：限制匹配长度
-				results = append(results, ScanResult{
-					Source: source,
-					Rule:   ruleName,
-					Match:  string(match), // 需要转换为 string
-				})
+	for _, ruleName := range slices.Sorted(maps.Keys(regexRules)) {
+		ruleResults, more := findRuleMatches(source, ruleName, regexRules[ruleName], content, bounds)
+		if more {
+			truncated = append(truncated, ruleName)
+		}
+		if !emit(ruleResults) {
+			break
+		}
+	}
+	return truncated
+}
+
+// findRuleMatches 查找单条正则规则的匹配，不满足 bounds 长度限制的匹配会被丢弃
+// 设置了 bounds.maxPerRule 时最多返回 maxPerRule 处匹配，找到更多匹配后立即停止查找，
+// 此时 more 为 true 表示还有未记录的匹配
+func findRuleMatches(source, ruleName string, reg *regexp.Regexp, content []byte, bounds matchBounds) (results []ScanResult, more bool) {
+	// FindAllIndex 同时给出匹配位置，用于计算行号；-1 表示查找所有匹配项
+	limit := -1
+	if bounds.maxPerRule > 0 {
+		limit = bounds.maxPerRule + 1
+	}
+	for {
+		matches := reg.FindAllIndex(content, limit)
+		results = results[:0]
+		for _, loc := range matches {
+			// 检查匹配是否为空、过短或过长 (可选，防止意外匹配)
+			match, offset, ok := bounds.apply(content, loc[0], loc[1])
+			if !ok {
+				continue
 			}
+			if bounds.maxPerRule > 0 && len(results) == bounds.maxPerRule {
+				return results, true
+			}
+			results = append(results, ScanResult{
+				Source:  source,
+				Rule:    ruleName,
+				Pattern: reg.String(),
+				Match:   match,
+				Offset:  offset,
+			})
+		}
+		// 匹配数未达到查找上限说明已找到全部匹配；否则部分匹配被 bounds 丢弃，扩大上限重新查找
+		if limit < 0 || len(matches) < limit {
+			return results, false
 		}
+		limit *= 2
 	}
-	return results
 }
 
-// processRegexRulesConcurrently 并行处理正则表达式规则
-func processRegexRulesConcurrently(source string, content []byte, regexRules map[string]*regexp.Regexp) []ScanResult {
-	resultChan := make(chan ScanResult, len(regexRules)*5) // 估算通道大小
+// processRegexRulesConcurrently 并行处理正则表达式规则，结果在调用方的 goroutine 中按规则名顺序交给 emit:
+// 某条规则查找完成后，只要排在它前面的规则都已完成，其结果立即输出，因此输出顺序与串行处理一致；
+// emit 返回 false 时不再输出后续结果，worker 也不再查找尚未开始的规则
+// 不满足 bounds 长度限制的匹配会被丢弃；truncated 为匹配数超过 -max-matches-per-rule 的规则
+// 使用固定数量 (workers) 的 goroutine 从规则通道中取规则，避免规则很多时为每条规则创建一个 goroutine
+func processRegexRulesConcurrently(source string, content []byte, regexRules map[string]*regexp.Regexp, bounds matchBounds, workers int, emit func([]ScanResult) bool) (truncated []string) {
+	names := slices.Sorted(maps.Keys(regexRules))
+
+	type ruleBatch struct {
+		index   int
+		results []ScanResult
+		more    bool
+	}
+	resultChan := make(chan ruleBatch, len(names)) // 每条规则的结果作为一批发送
 	var wg sync.WaitGroup
+	var stopped atomic.Bool
+
+	ruleQueue := make(chan int, len(names))
+	for i := range names {
+		ruleQueue <- i
+	}
+	close(ruleQueue)
 
-	for ruleName, reg := range regexRules {
+	workers = max(1, min(workers, len(names)))
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
-		go func(name string, regex *regexp.Regexp) {
+		go func() {
 			defer wg.Done()
-			// 每个 goroutine 查找自己的匹配
-			matches := regex.FindAll(content, -1)
-			for _, match := range matches {
-				// 检查匹配是否为空或过长
-				if len(match) > 0 && len(match) < 1024 {
-					resultChan <- ScanResult{
-						Source: source,
-						Rule:   name,
-						Match:  string(match),
-					}
+			for index := range ruleQueue {
+				if stopped.Load() {
+					continue // 已停止输出，跳过剩余规则
 				}
+				// 每个 worker 依次查找所取规则的匹配
+				name := names[index]
+				ruleResults, more := findRuleMatches(source, name, regexRules[name], content, bounds)
+				resultChan <- ruleBatch{index: index, results: ruleResults, more: more}
 			}
-		}(ruleName, reg)
+		}()
 	}
 
 	// 启动一个 goroutine 等待所有规则处理完成，然后关闭通道
@@ -166,21 +552,639 @@ func processRegexRulesConcurrently(source string, content []byte, regexRules map
 		close(resultChan)
 	}()
 
-	// 从通道收集结果
-	results := make([]ScanResult, 0, len(resultChan)) // 预估容量
-	for result := range resultChan {
-		results = append(results, result)
+	// 暂存先于前面规则完成的结果，按规则名顺序依次交给 emit
+	batches := make([]*ruleBatch, len(names))
+	next := 0
+	for batch := range resultChan {
+		batches[batch.index] = &batch
+		for ; !stopped.Load() && next < len(names) && batches[next] != nil; next++ {
+			if batches[next].more {
+				truncated = append(truncated, names[next])
+			}
+			if !emit(batches[next].results) {
+				stopped.Store(true)
+			}
+			batches[next] = nil
+		}
 	}
 
-	return results
+	return truncated
+}
+
+// matchBounds 控制正则匹配结果的长度限制和空白处理
+type matchBounds struct {
+	minLen int  // 匹配的最小长度 (-min-match-len)
+	maxLen int  // 长度达到该值的匹配被丢弃 (-max-match-len)
+	trim   bool // 去除匹配首尾的空白，只含空白的匹配被丢弃 (-trim-matches)
+	// maxPerRule 每条规则在一个来源中最多记录的匹配数 (-max-matches-per-rule)，0 表示不限制
+	maxPerRule int
+}
+
+func newMatchBounds(cfg *config.AppConfig) matchBounds {
+	return matchBounds{minLen: cfg.MinMatchLen, maxLen: cfg.MaxMatchLen, trim: cfg.TrimMatches, maxPerRule: cfg.MaxMatchesPerRule}
+}
+
+// apply 校验 content[start:end] 处的匹配，返回 (去除空白后的) 匹配内容及其偏移
+func (b matchBounds) apply(content []byte, start, end int) (string, int, bool) {
+	match := content[start:end]
+	if b.trim {
+		trimmed := bytes.TrimLeftFunc(match, unicode.IsSpace)
+		start += len(match) - len(trimmed)
+		match = bytes.TrimRightFunc(trimmed, unicode.IsSpace)
+	}
+	if len(match) == 0 || len(match) < b.minLen || len(match) >= b.maxLen {
+		return "", 0, false
+	}
+	return string(match), start, true
+}
+
+// gzipMagic 是 gzip 数据的文件头魔数
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// maybeGunzip 若内容以 gzip 魔数开头则解压，不依赖 Content-Type/Content-Encoding 等外部信息
+// 解压后的内容最多保留 limit 字节；返回值 truncated 表示解压结果超出限制被截断
+func maybeGunzip(content []byte, limit int64) (decompressed []byte, ok bool, truncated bool, err error) {
+	if !bytes.HasPrefix(content, gzipMagic) {
+		return content, false, false, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return content, false, false, fmt.Errorf("解析 gzip 头失败: %w", err)
+	}
+	defer reader.Close()
+
+	decompressed, err = io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return content, false, false, fmt.Errorf("解压 gzip 数据失败: %w", err)
+	}
+	if int64(len(decompressed)) > limit {
+		return decompressed[:limit], true, true, nil
+	}
+	return decompressed, true, false, nil
+}
+
+// unratedSeverityFile 是按严重级别输出时，未设置严重级别的规则对应的文件名
+const unratedSeverityFile = "unrated.txt"
+
+// outputPathFor 根据输出选项确定单条结果应写入的文件
+func outputPathFor(cfg *config.AppConfig, result ScanResult) string {
+	if cfg.BySeverity {
+		if result.Severity == "" {
+			return filepath.Join(cfg.OutputDir, unratedSeverityFile)
+		}
+		return filepath.Join(cfg.OutputDir, result.Severity+".txt")
+	}
+	// -by-rule: 每条规则一个子目录，同一来源的发现可能分散写入多个规则目录
+	outputDir := cfg.OutputDir
+	if cfg.ByRule {
+		outputDir = filepath.Join(outputDir, utils.SanitizeFilename(result.Rule))
+	}
+	if cfg.MirrorTree && cfg.Mode == "localScan" && cfg.HARInput == "" {
+		return mirrorOutputPath(outputDir, result.Source)
+	}
+	key := result.Source
+	if cfg.HARInput != "" {
+		key = harEntryKey(key) // 同一条 HAR 记录的 URL、请求体和响应体写入同一个结果文件
+	}
+	if cfg.GroupByHost {
+		if host := sourceHost(result.Source); host != "" {
+			key = utils.HostFilename(host) + ".txt"
+		}
+	}
+	return GetOutputFilePath(outputDir, key, cfg.ShardOutput)
+}
+
+// mirrorOutputPath 返回按来源原始目录结构存放的结果文件路径 (-mirror-tree)，
+// 例如 src/app/main.js -> <outputDir>/src/app/main.js.txt
+// 绝对路径去掉卷名和开头的分隔符，".." 替换为 "__"，避免结果写到输出目录之外
+func mirrorOutputPath(outputDir, source string) string {
+	cleaned := filepath.Clean(source)
+	cleaned = strings.TrimPrefix(cleaned, filepath.VolumeName(cleaned))
+	parts := []string{outputDir}
+	for _, part := range strings.Split(filepath.ToSlash(cleaned), "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			part = "__"
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 1 {
+		parts = append(parts, "_")
+	}
+	parts[len(parts)-1] += ".txt"
+	return filepath.Join(parts...)
+}
+
+// sourceHost 返回 URL 来源的主机名 (含端口)，本地文件等非 URL 来源返回空
+func sourceHost(source string) string {
+	u, err := url.Parse(source)
+	if err != nil || u.Scheme == "" {
+		return ""
+	}
+	return u.Host
+}
+
+// resultWriter 负责一次扫描中所有结果的输出：
+// 按来源 (或严重级别) 写入文本文件，以及可选的 NDJSON 文件 (-ndjson)；
+// 指定 -socket 时发现以 NDJSON 格式发送到套接字，不再写入文本文件
+//
+// 结果文件写入失败 (例如磁盘已满或权限变化) 时，失败的发现暂存在内存中，在后续写入和 Close 时重试；
+// 到 Close 时仍无法写入的发现会打印到标准错误，并由 Close 返回错误，使扫描以非零状态退出
+type resultWriter struct {
+	cfg      *config.AppConfig
+	ndjson   *ndjsonWriter
+	socket   *ndjsonWriter           // -socket 的 NDJSON 流
+	sqlite   *sqliteWriter           // -sqlite 的数据库输出
+	clean    *cleanRecorder          // -record-clean 的无发现来源记录
+	manifest *manifestRecorder       // -manifest 的覆盖清单
+	index    *resultIndex            // -index 的结果索引
+	unique   *uniqueIndex            // -global-dedup 的整次运行唯一发现汇总
+	sarif    *sarifCollector         // -sarif 和 -upload-github 收集的发现
+	github   httpclient.GitHubUpload // -upload-github 的上传目标
+	outputs  *outputFiles            // 结果文件已存在时的处理 (-on-exist)
+	limit    *outputLimit            // 结果文件总大小上限 (-max-output-size)
+	findings atomic.Int64            // 已成功写入的发现数
+
+	mu        sync.Mutex   // 保护以下字段
+	pending   []ScanResult // 写入结果文件失败、等待重试的发现
+	dropped   int          // 超出暂存上限而丢弃的发现数
+	lastRetry time.Time    // 最近一次重试暂存发现的时间
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+const (
+	maxPendingFindings   = 10000           // 写入失败时在内存中暂存的发现数上限
+	pendingRetryInterval = 5 * time.Second // 重试写入暂存发现的最短间隔
+)
+
+// newResultWriter 根据配置创建结果输出器，调用方需在扫描结束后调用 Close
+func newResultWriter(cfg *config.AppConfig, compiledRules *rules.CompiledRules) (*resultWriter, error) {
+	rw := &resultWriter{cfg: cfg, outputs: newOutputFiles(cfg), limit: newOutputLimit(cfg.MaxOutputSize)}
+	if cfg.ResultTemplate != nil {
+		if err := checkResultTemplate(cfg.ResultTemplate); err != nil {
+			return nil, fmt.Errorf("模板文件 '%s' 无效: %w", cfg.TemplateFile, err)
+		}
+	}
+	// 在扫描开始前确定上传目标，缺少令牌或无法确定提交时不必等到扫描结束才报错
+	if cfg.UploadGitHub != "" {
+		target, err := githubUploadTarget(cfg)
+		if err != nil {
+			return nil, err
+		}
+		rw.github = target
+	}
+	if cfg.SARIFFile != "" || cfg.UploadGitHub != "" {
+		rw.sarif = newSARIFCollector(compiledRules, sarifRoot(cfg))
+	}
+	if cfg.NDJSONFile != "" {
+		flush := flushPolicy{interval: cfg.FlushInterval, bytes: cfg.FlushBytes}
+		ndjson, err := newNDJSONWriter(cfg.NDJSONFile, compiledRules, flush)
+		if err != nil {
+			return nil, err
+		}
+		rw.ndjson = ndjson
+	}
+	if cfg.Socket != "" {
+		socket, err := newNDJSONSocketWriter(cfg.Socket, compiledRules)
+		if err != nil {
+			if rw.ndjson != nil {
+				rw.ndjson.Close()
+			}
+			return nil, err
+		}
+		rw.socket = socket
+	}
+	if cfg.RecordClean != "" {
+		clean, err := newCleanRecorder(cfg.RecordClean)
+		if err != nil {
+			if rw.ndjson != nil {
+				rw.ndjson.Close()
+			}
+			if rw.socket != nil {
+				rw.socket.Close()
+			}
+			return nil, err
+		}
+		rw.clean = clean
+	}
+	if cfg.SQLiteFile != "" {
+		sqlite, err := newSQLiteWriter(cfg.SQLiteFile, compiledRules)
+		if err != nil {
+			if rw.ndjson != nil {
+				rw.ndjson.Close()
+			}
+			if rw.socket != nil {
+				rw.socket.Close()
+			}
+			if rw.clean != nil {
+				rw.clean.Close()
+			}
+			return nil, err
+		}
+		rw.sqlite = sqlite
+	}
+	if cfg.Manifest != "" {
+		rw.manifest = newManifestRecorder(cfg.Manifest)
+	}
+	if cfg.ResultIndex {
+		rw.index = newResultIndex(cfg.OutputDir)
+	}
+	if cfg.GlobalDedup {
+		rw.unique = newUniqueIndex()
+	}
+	return rw, nil
+}
+
+// recordSource 记录一个扫描完成的来源: 写入覆盖清单 (-manifest)，没有发现时记录到 -record-clean；
+// size 为扫描的内容大小，findings 为发现数，started 为开始处理该来源的时间
+func (rw *resultWriter) recordSource(source string, size, findings int, started time.Time) {
+	if rw.manifest != nil {
+		rw.manifest.record(source, size, findings, time.Since(started))
+	}
+	if rw.clean != nil && findings == 0 {
+		rw.clean.record(source)
+	}
+}
+
+// write 将一个来源的结果按输出文件分组写入，返回写入的文本结果文件列表
+// 写入结果文件失败的发现会被暂存并稍后重试，此时返回的错误说明了暂存的数量
+func (rw *resultWriter) write(results []ScanResult) ([]string, error) {
+	if rw.limit.exceeded() {
+		rw.limit.drop(len(results))
+		return nil, nil
+	}
+	if rw.cfg.SortByConfidence {
+		slices.SortStableFunc(results, func(a, b ScanResult) int { return b.Confidence - a.Confidence })
+	}
+	if rw.socket != nil {
+		if _, err := rw.socket.write(results); err != nil {
+			return nil, err
+		}
+	}
+
+	rw.retryPending(false)
+	if rw.unique != nil {
+		rw.unique.add(results)
+	}
+	if rw.sarif != nil {
+		rw.sarif.add(results)
+	}
+
+	paths, failed, writeErr := rw.writeFiles(results)
+	if len(failed) > 0 {
+		rw.buffer(failed)
+	}
+	if rw.ndjson != nil {
+		n, err := rw.ndjson.write(results)
+		rw.limit.add(n)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if rw.sqlite != nil {
+		if err := rw.sqlite.write(results); err != nil {
+			return nil, err
+		}
+	}
+	if rw.cfg.FindingsOnly {
+		if err := printFindings(results, rw.cfg.ResultTemplate); err != nil {
+			return nil, err
+		}
+	}
+	rw.findings.Add(int64(len(results) - len(failed)))
+	if writeErr != nil {
+		return paths, fmt.Errorf("%w (%d 条发现已暂存在内存中，稍后重试)", writeErr, len(failed))
+	}
+	return paths, nil
+}
+
+// sourceWriter 返回输出一个来源结果的函数:
+// 指定 -stream-findings 时，sink 在查找过程中立即写出每一批结果，finish 只汇总已写入的文件和错误；
+// 否则 sink 为 nil，由 finish 一次写出该来源的全部结果。decorate 不为 nil 时在写出前对每条结果调用
+func (rw *resultWriter) sourceWriter(decorate func(*ScanResult)) (sink resultSink, finish func([]ScanResult) ([]string, error)) {
+	apply := func(results []ScanResult) {
+		if decorate != nil {
+			for i := range results {
+				decorate(&results[i])
+			}
+		}
+	}
+	if !rw.cfg.StreamFindings {
+		return nil, func(results []ScanResult) ([]string, error) {
+			apply(results)
+			return rw.write(results)
+		}
+	}
+	return rw.streamWriter(decorate)
+}
+
+// streamWriter 返回立即写出每一批结果的 sink，finish 只汇总已写入的文件和错误 (-stream-findings、-stream-body)
+func (rw *resultWriter) streamWriter(decorate func(*ScanResult)) (sink resultSink, finish func([]ScanResult) ([]string, error)) {
+	apply := func(results []ScanResult) {
+		if decorate != nil {
+			for i := range results {
+				decorate(&results[i])
+			}
+		}
+	}
+	var paths []string
+	var errs []error
+	sink = func(results []ScanResult) {
+		apply(results)
+		written, err := rw.write(results)
+		for _, path := range written {
+			if !slices.Contains(paths, path) {
+				paths = append(paths, path)
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return sink, func([]ScanResult) ([]string, error) {
+		return paths, errors.Join(errs...)
+	}
+}
+
+// writeFiles 将结果按输出文件分组写入，返回成功写入的文件列表和写入失败的发现
+func (rw *resultWriter) writeFiles(results []ScanResult) (paths []string, failed []ScanResult, err error) {
+	if rw.socket != nil {
+		return []string{rw.cfg.Socket}, nil, nil
+	}
+	var order []string
+	grouped := make(map[string][]ScanResult)
+	for _, result := range results {
+		path := outputPathFor(rw.cfg, result)
+		if _, ok := grouped[path]; !ok {
+			order = append(order, path)
+		}
+		grouped[path] = append(grouped[path], result)
+	}
+
+	for _, path := range order {
+		// 结果文件在本次运行前已存在时按 -on-exist 跳过、清空或改写到编号文件
+		actual, ok, resolveErr := rw.outputs.resolve(path)
+		if resolveErr != nil {
+			failed = append(failed, grouped[path]...)
+			err = resolveErr
+			continue
+		}
+		if !ok {
+			continue
+		}
+		n, writeErr := WriteResultsToFile(actual, grouped[path], rw.cfg.Verbose && !rw.cfg.Quiet, rw.cfg.ResultTemplate)
+		if writeErr != nil {
+			failed = append(failed, grouped[path]...)
+			err = writeErr
+			continue
+		}
+		rw.limit.add(n)
+		if rw.index != nil {
+			rw.index.record(actual, grouped[path])
+		}
+		paths = append(paths, actual)
+	}
+	return paths, failed, err
+}
+
+// buffer 暂存写入失败的发现，超出 maxPendingFindings 的部分被丢弃并计数
+func (rw *resultWriter) buffer(failed []ScanResult) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	room := max(0, maxPendingFindings-len(rw.pending))
+	if len(failed) > room {
+		rw.dropped += len(failed) - room
+		failed = failed[:room]
+	}
+	rw.pending = append(rw.pending, failed...)
+}
+
+// retryPending 重试写入暂存的发现；force 为 false 时距上次重试不足 pendingRetryInterval 则跳过
+func (rw *resultWriter) retryPending(force bool) {
+	rw.mu.Lock()
+	if len(rw.pending) == 0 || (!force && time.Since(rw.lastRetry) < pendingRetryInterval) {
+		rw.mu.Unlock()
+		return
+	}
+	pending := rw.pending
+	rw.pending = nil
+	rw.lastRetry = time.Now()
+	rw.mu.Unlock()
+
+	_, failed, _ := rw.writeFiles(pending)
+	rw.findings.Add(int64(len(pending) - len(failed)))
+	if len(failed) > 0 {
+		rw.buffer(failed)
+	} else if !rw.cfg.Quiet {
+		i18n.Printf("提示: 之前写入失败的 %d 条发现已成功写入结果文件。\n", len(pending))
+	}
+}
+
+// printFindings 将一个来源的结果作为整体打印到 FindingsOutput，避免多个来源的行交错
+func printFindings(results []ScanResult, tmpl *template.Template) error {
+	var buf bytes.Buffer
+	if err := formatResults(&buf, results, false, tmpl); err != nil {
+		return err
+	}
+
+	fileWriteMutex.Lock()
+	defer fileWriteMutex.Unlock()
+	if _, err := FindingsOutput.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("打印发现失败: %w", err)
+	}
+	return nil
+}
+
+// findingCount 返回已成功写入的发现数
+func (rw *resultWriter) findingCount() int64 {
+	return rw.findings.Load()
+}
+
+// Close 最后一次重试写入暂存的发现并关闭所有打开的输出，可以重复调用
+// 仍有发现无法写入时，将其打印到标准错误并返回汇总错误
+func (rw *resultWriter) Close() error {
+	rw.closeOnce.Do(func() {
+		rw.retryPending(true)
+
+		var errs []error
+		if rw.ndjson != nil {
+			if err := rw.ndjson.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if rw.socket != nil {
+			if err := rw.socket.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if rw.sqlite != nil {
+			if written, err := rw.sqlite.Close(); err != nil {
+				errs = append(errs, err)
+			} else if !rw.cfg.Quiet {
+				i18n.Printf("%d 条发现已写入 SQLite 数据库: %s\n", written, rw.cfg.SQLiteFile)
+			}
+		}
+		if rw.clean != nil {
+			if err := rw.clean.Close(); err != nil {
+				errs = append(errs, err)
+			} else if !rw.cfg.Quiet {
+				i18n.Printf("%d 个没有发现的来源已记录到: %s\n", rw.clean.count, rw.cfg.RecordClean)
+			}
+		}
+		if rw.unique != nil {
+			path := filepath.Join(rw.cfg.OutputDir, uniqueFindingsFile)
+			if unique, total, err := rw.unique.write(path); err != nil {
+				errs = append(errs, err)
+			} else if !rw.cfg.Quiet {
+				i18n.Printf("全局去重: %d 条发现中有 %d 条唯一发现 (规则名 + 匹配内容)，已写入: %s\n", total, unique, path)
+			}
+		}
+		if rw.manifest != nil {
+			if count, err := rw.manifest.write(rw.cfg.RunID, rw.cfg.Mode); err != nil {
+				errs = append(errs, err)
+			} else if !rw.cfg.Quiet {
+				i18n.Printf("覆盖清单 (%d 个来源) 已写入: %s\n", count, rw.cfg.Manifest)
+			}
+		}
+
+		if rw.index != nil {
+			path := filepath.Join(rw.cfg.OutputDir, resultIndexFile)
+			if count, err := rw.index.write(path, rw.cfg.RunID, rw.cfg.Mode); err != nil {
+				errs = append(errs, err)
+			} else if !rw.cfg.Quiet {
+				i18n.Printf("结果索引 (%d 个来源) 已写入: %s\n", count, path)
+			}
+		}
+
+		if rw.sarif != nil && rw.cfg.SARIFFile != "" {
+			if err := rw.sarif.writeSARIF(rw.cfg.SARIFFile); err != nil {
+				errs = append(errs, err)
+			} else if !rw.cfg.Quiet {
+				i18n.Printf("SARIF 报告 (%d 条发现) 已写入: %s\n", rw.sarif.count(), rw.cfg.SARIFFile)
+			}
+		}
+		if rw.sarif != nil && rw.cfg.UploadGitHub != "" {
+			if err := rw.sarif.uploadGitHub(rw.cfg, rw.github); err != nil {
+				errs = append(errs, fmt.Errorf("上传 SARIF 到 GitHub 仓库 %s 失败: %w", rw.cfg.UploadGitHub, err))
+			}
+		}
+
+		if err := rw.limit.summary(rw.findingCount()); err != nil {
+			errs = append(errs, err)
+		}
+
+		rw.mu.Lock()
+		pending, dropped := rw.pending, rw.dropped
+		rw.mu.Unlock()
+		if len(pending)+dropped > 0 {
+			var buf bytes.Buffer
+			for _, result := range pending {
+				formatResultLine(&buf, result, false)
+			}
+			i18n.Fprintf(os.Stderr, "\n以下 %d 条发现无法写入结果文件:\n%s", len(pending), buf.String())
+			if dropped > 0 {
+				errs = append(errs, fmt.Errorf("%d 条发现无法写入结果文件 (其中 %d 条超出内存暂存上限 %d 已丢弃)", len(pending)+dropped, dropped, maxPendingFindings))
+			} else {
+				errs = append(errs, fmt.Errorf("%d 条发现无法写入结果文件", len(pending)))
+			}
+		}
+		rw.closeErr = errors.Join(errs...)
+	})
+	return rw.closeErr
+}
+
+// lineIndex 记录内容中每一行的起始偏移，用于将字节偏移转换为行号
+type lineIndex []int
+
+func newLineIndex(content []byte) lineIndex {
+	starts := lineIndex{0}
+	for i, b := range content {
+		if b == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// lineAt 返回字节偏移所在的行号 (从 1 开始)
+func (idx lineIndex) lineAt(offset int) int {
+	return sort.Search(len(idx), func(i int) bool { return idx[i] > offset })
+}
+
+// contentIndex 记录已扫描内容的哈希 -> 首个来源，用于跳过内容完全相同的重复来源
+// 设置了 bloom 时改用布隆过滤器记录哈希，内存固定但不再记录首个来源，且可能误判
+// 可被多个 goroutine 并发使用
+type contentIndex struct {
+	mu         sync.Mutex
+	firstSeen  map[[sha256.Size]byte]string
+	groups     map[string][]string // 首个来源 -> 内容与之相同而被跳过的来源 (仅精确模式)
+	bloom      *bloomFilter
+	duplicates int
+}
+
+// newContentIndex 创建内容索引，bloom 为 nil 时使用精确的哈希集合
+func newContentIndex(bloom *bloomFilter) *contentIndex {
+	if bloom != nil {
+		return &contentIndex{bloom: bloom}
+	}
+	return &contentIndex{firstSeen: make(map[[sha256.Size]byte]string), groups: make(map[string][]string)}
+}
+
+// markSeen 记录内容的哈希。若相同内容此前已出现过，返回首个来源和 true
+// 使用布隆过滤器时首个来源未知，返回空字符串
+func (idx *contentIndex) markSeen(content []byte, source string) (string, bool) {
+	hash := sha256.Sum256(content)
+
+	if idx.bloom != nil {
+		if !idx.bloom.add(hash) {
+			return "", false
+		}
+		idx.mu.Lock()
+		idx.duplicates++
+		idx.mu.Unlock()
+		return "", true
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if first, ok := idx.firstSeen[hash]; ok {
+		idx.duplicates++
+		idx.groups[first] = append(idx.groups[first], source)
+		return first, true
+	}
+	idx.firstSeen[hash] = source
+	return "", false
+}
+
+// duplicateCount 返回被判定为重复而跳过的来源数量
+func (idx *contentIndex) duplicateCount() int {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return idx.duplicates
 }
 
 // GetOutputFilePath 生成结果文件的完整路径
-func GetOutputFilePath(outputDir, sourceIdentifier string) string {
+// 清理后的文件名会附加原始来源的短哈希 (例如 example.com_main_1a2b3c4d.js)，
+// 避免仅非法字符不同、被截断或同名不同目录的来源写入同一个文件
+// shard 为 true 时按清理后文件名的哈希前缀分散到子目录 (例如 results/3f/...)，
+// 避免单个目录中文件过多
+func GetOutputFilePath(outputDir, sourceIdentifier string, shard bool) string {
 	sanitized := utils.SanitizeFilename(sourceIdentifier)
 	// 如果清理后的文件名没有扩展名，添加 .txt
-	if filepath.Ext(sanitized) == "" {
-		sanitized += ".txt"
+	ext := filepath.Ext(sanitized)
+	if ext == "" {
+		ext = ".txt"
+	}
+	sourceHash := sha256.Sum256([]byte(sourceIdentifier))
+	sanitized = strings.TrimSuffix(sanitized, filepath.Ext(sanitized)) + "_" + hex.EncodeToString(sourceHash[:4]) + ext
+
+	if shard {
+		hash := sha256.Sum256([]byte(sanitized))
+		return filepath.Join(outputDir, hex.EncodeToString(hash[:1]), sanitized)
 	}
 	return filepath.Join(outputDir, sanitized)
 }
@@ -0,0 +1,70 @@
+package scan
+
+import (
+	"jsleaksscan/internal/rules"
+	"math"
+)
+
+// defaultConfidence 是规则未声明置信度 (confidence) 时的基础置信度
+const defaultConfidence = 50
+
+// minEntropyLength 计算熵的最短匹配长度，过短的匹配熵值不可靠，不参与调整
+const minEntropyLength = 8
+
+// shannonEntropy 计算字符串按字节的香农熵 (比特/字节)，随机生成的密钥通常在 4 以上，重复的占位符 (如 xxxxxxxx) 接近 0
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / float64(len(s))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// scoreConfidence 计算发现的综合置信度 (0-100)，用于排序和 -min-confidence 过滤:
+// 以规则声明的置信度 (未声明时为 defaultConfidence) 为基础，
+// 匹配内容的熵高时提高、熵很低时 (多为占位符或示例值) 降低；
+// 规则正则包含捕获组 (通常以键名等上下文锚定取值) 或规则设置了 confirm 校验时提高
+// (未通过 confirm 的匹配在计算置信度前已被丢弃，因此这是规则级别的加分，同一规则的发现都会得到)；
+// 匹配内容中的 JWT 解码成功时提高，已过期时降低
+func scoreConfidence(result ScanResult, compiledRules *rules.CompiledRules) int {
+	score := compiledRules.Meta[result.Rule].Confidence
+	if score == 0 {
+		score = defaultConfidence
+	}
+
+	if len(result.Match) >= minEntropyLength {
+		switch entropy := shannonEntropy(result.Match); {
+		case entropy >= 4:
+			score += 15
+		case entropy >= 3:
+			score += 5
+		case entropy < 2:
+			score -= 25
+		}
+	}
+	if reg, ok := compiledRules.Regex[result.Rule]; ok && reg.NumSubexp() > 0 {
+		score += 10
+	}
+	if filter, ok := compiledRules.Filters[result.Rule]; ok && filter.Confirm != nil {
+		score += 10
+	}
+	if result.JWT != nil {
+		if result.JWT.Expired {
+			score -= 10
+		} else {
+			score += 10
+		}
+	}
+	return max(0, min(100, score))
+}
@@ -0,0 +1,113 @@
+package scan
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// dataURISourceSuffix 附加在来源后作为内嵌 data: URI 的来源标识 (例如 app.js#data-uri)
+// 同一来源中的第 2 个及之后的 data: URI 依次为 #data-uri-2、#data-uri-3 ...
+const dataURISourceSuffix = "#data-uri"
+
+const (
+	minDataURIPayload = 16               // 短于该长度的载荷 (通常是占位图片等) 不解码
+	maxDataURIPayload = 10 * 1024 * 1024 // 载荷的最大处理长度，更长的 data: URI 被跳过
+)
+
+// dataURIPattern 匹配 data: URI 的头部: data:[<媒体类型>][;参数=值]*[;base64],
+var dataURIPattern = regexp.MustCompile(`data:([\w.+-]+/[\w.+-]+)?((?:;[\w.+-]+=[\w.+-]+)*)(;base64)?,`)
+
+// dataURI 是从内容中解析出的单个 data: URI
+type dataURI struct {
+	mediaType string
+	payload   []byte // 解码后的载荷
+}
+
+// isDataURIPayloadEnd 判断字节是否为 data: URI 载荷的结束符 (引号、空白、括号和尖括号)
+func isDataURIPayloadEnd(c byte) bool {
+	switch c {
+	case '"', '\'', '`', ' ', '\t', '\r', '\n', ')', '<', '>':
+		return true
+	}
+	return false
+}
+
+// isBinaryMediaType 判断媒体类型是否为图片、字体、音视频等二进制资源，这些载荷不扫描
+// SVG 是文本格式，可能包含脚本，因此仍会扫描
+func isBinaryMediaType(mediaType string) bool {
+	mediaType = strings.ToLower(mediaType)
+	if mediaType == "image/svg+xml" {
+		return false
+	}
+	for _, prefix := range []string{"image/", "font/", "audio/", "video/"} {
+		if strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractDataURIs 查找内容中的 data: URI 并解码其载荷
+// base64 载荷兼容标准和 URL 安全字母表、有无填充；其余载荷按百分号编码解码。
+// 二进制媒体类型、过短或过长以及无法解码的载荷被跳过
+func extractDataURIs(content []byte) []dataURI {
+	var uris []dataURI
+	for _, loc := range dataURIPattern.FindAllSubmatchIndex(content, -1) {
+		end := loc[1]
+		for end < len(content) && !isDataURIPayloadEnd(content[end]) {
+			end++
+		}
+		raw := content[loc[1]:end]
+		if len(raw) < minDataURIPayload || len(raw) > maxDataURIPayload {
+			continue
+		}
+		mediaType := "text/plain" // 省略媒体类型时的默认值 (RFC 2397)
+		if loc[2] >= 0 {
+			mediaType = string(content[loc[2]:loc[3]])
+		}
+		if isBinaryMediaType(mediaType) {
+			continue
+		}
+
+		var payload []byte
+		if loc[6] >= 0 {
+			decoded, err := decodeBase64Payload(string(raw))
+			if err != nil {
+				continue
+			}
+			payload = decoded
+		} else {
+			decoded, err := url.PathUnescape(string(raw))
+			if err != nil {
+				decoded = string(raw)
+			}
+			payload = []byte(decoded)
+		}
+		uris = append(uris, dataURI{mediaType: mediaType, payload: payload})
+	}
+	return uris
+}
+
+// decodeBase64Payload 依次尝试标准、无填充、URL 安全和无填充 URL 安全的 base64 解码
+func decodeBase64Payload(s string) ([]byte, error) {
+	var lastErr error
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		decoded, err := enc.DecodeString(s)
+		if err == nil {
+			return decoded, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// dataURISource 返回来源中第 n 个 (从 1 开始) data: URI 的来源标识
+func dataURISource(source string, n int) string {
+	if n == 1 {
+		return source + dataURISourceSuffix
+	}
+	return fmt.Sprintf("%s%s-%d", source, dataURISourceSuffix, n)
+}
@@ -0,0 +1,118 @@
+package scan
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// uniqueFindingsFile 是 -global-dedup 在输出目录中写入的唯一发现汇总文件名
+const uniqueFindingsFile = "unique-findings.txt"
+
+// duplicateFilesFile 是 -dedup-content 在输出目录中写入的相同内容文件对应关系的文件名
+const duplicateFilesFile = "duplicate-files.txt"
+
+// writeDuplicateGroups 将内容相同的来源分组写入 path: 每组第一行为实际扫描的来源 (其结果文件中的发现同样适用于该组其他来源)，
+// 之后逐行列出被跳过的来源。返回分组数
+func (idx *contentIndex) writeDuplicateGroups(path string) (int, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	firsts := slices.Sorted(maps.Keys(idx.groups))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# 内容相同的文件: %d 组，跳过了 %d 个文件 (每组只扫描第一个文件，其发现同样适用于组内其他文件)\n", len(firsts), idx.duplicates)
+	for _, first := range firsts {
+		duplicates := slices.Sorted(slices.Values(idx.groups[first]))
+		fmt.Fprintf(&buf, "%s (另有 %d 个相同的文件)\n", first, len(duplicates))
+		for _, duplicate := range duplicates {
+			fmt.Fprintf(&buf, "    %s\n", duplicate)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, fmt.Errorf("创建输出目录 '%s' 失败: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return 0, fmt.Errorf("写入相同内容文件列表 '%s' 失败: %w", path, err)
+	}
+	return len(firsts), nil
+}
+
+// uniqueFinding 是整次运行中的一个唯一发现 (规则名 + 匹配内容)
+type uniqueFinding struct {
+	rule     string
+	match    string
+	severity string
+	sources  map[string]struct{} // 出现该发现的来源 (集合，来源数很多时添加仍为常数时间)
+	count    int                 // 出现的总次数 (同一来源中多次出现分别计数)
+}
+
+// uniqueIndex 按 (规则名, 匹配内容) 汇总整次运行的发现 (-global-dedup)，可被多个 goroutine 并发使用
+type uniqueIndex struct {
+	mu       sync.Mutex
+	findings map[[2]string]*uniqueFinding
+	total    int
+}
+
+func newUniqueIndex() *uniqueIndex {
+	return &uniqueIndex{findings: make(map[[2]string]*uniqueFinding)}
+}
+
+// add 将一批发现计入汇总
+func (idx *uniqueIndex) add(results []ScanResult) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, result := range results {
+		key := [2]string{result.Rule, result.Match}
+		finding, ok := idx.findings[key]
+		if !ok {
+			finding = &uniqueFinding{rule: result.Rule, match: result.Match, severity: result.Severity, sources: make(map[string]struct{})}
+			idx.findings[key] = finding
+		}
+		finding.sources[result.Source] = struct{}{}
+		finding.count++
+		idx.total++
+	}
+}
+
+// write 将唯一发现写入 path: 按出现的来源数从多到少排序，每个发现后按名称顺序逐行列出其来源。返回唯一发现数和发现总数
+func (idx *uniqueIndex) write(path string) (unique, total int, err error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	findings := make([]*uniqueFinding, 0, len(idx.findings))
+	sources := make(map[string]bool)
+	for _, finding := range idx.findings {
+		findings = append(findings, finding)
+		for source := range finding.sources {
+			sources[source] = true
+		}
+	}
+	slices.SortFunc(findings, func(a, b *uniqueFinding) int {
+		return cmp.Or(cmp.Compare(len(b.sources), len(a.sources)), cmp.Compare(a.rule, b.rule), cmp.Compare(a.match, b.match))
+	})
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# 唯一发现: %d 条 (共 %d 条发现，来自 %d 个来源)\n", len(findings), idx.total, len(sources))
+	for _, finding := range findings {
+		if finding.severity != "" {
+			fmt.Fprintf(&buf, "[%s] ", finding.severity)
+		}
+		fmt.Fprintf(&buf, "%s: %s (出现在 %d 个来源，共 %d 次)\n", finding.rule, finding.match, len(finding.sources), finding.count)
+		for _, source := range slices.Sorted(maps.Keys(finding.sources)) {
+			fmt.Fprintf(&buf, "    %s\n", source)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, 0, fmt.Errorf("创建输出目录 '%s' 失败: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return 0, 0, fmt.Errorf("写入唯一发现汇总 '%s' 失败: %w", path, err)
+	}
+	return len(findings), idx.total, nil
+}
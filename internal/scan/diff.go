@@ -0,0 +1,183 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"jsleaksscan/internal/config"
+	"jsleaksscan/internal/i18n"
+	"jsleaksscan/internal/rules"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// diffFile 是 git diff 中一个文件新增的行
+type diffFile struct {
+	path    string // 相对于仓库根目录的路径 (新文件名)
+	content []byte // 新增的行依次拼接，不同 hunk 之间以空行分隔，避免正则跨 hunk 匹配
+	lines   []int  // content 中第 i 行 (从 0 开始) 在新文件中的实际行号，hunk 之间的分隔行为 0
+}
+
+// scanGitDiff 只扫描 git 提交范围 (-diff，例如 main..HEAD) 中新增的行，结果中的行号为新文件中的实际行号
+// -d 为 git 仓库 (工作区) 目录；删除的行和已存在的内容不会被扫描，适合在 CI 中只拦截新引入的密钥
+func scanGitDiff(cfg *config.AppConfig, compiledRules *rules.CompiledRules, startTime time.Time) error {
+	i18n.Printf("开始扫描 git 差异: %s (仓库: %s)\n", cfg.DiffRange, cfg.LocalDir)
+
+	files, err := readGitDiff(cfg.LocalDir, cfg.DiffRange)
+	if err != nil {
+		return err
+	}
+	if !cfg.Quiet {
+		var added int
+		for _, file := range files {
+			for _, line := range file.lines {
+				if line > 0 {
+					added++
+				}
+			}
+		}
+		i18n.Printf("差异中有 %d 个文件新增了共 %d 行。\n", len(files), added)
+	}
+
+	out, err := newResultWriter(cfg, compiledRules)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	for _, file := range files {
+		source := filepath.Join(cfg.LocalDir, filepath.FromSlash(file.path))
+		if out.skipSource(source) {
+			continue
+		}
+		lines := file.lines
+		// 结果的行号按拼接内容计算，写出前换算为新文件中的实际行号
+		sink, finish := out.sourceWriter(func(result *ScanResult) {
+			if result.Line >= 1 && result.Line <= len(lines) {
+				result.Line = lines[result.Line-1]
+			}
+			if result.EndLine >= 1 && result.EndLine <= len(lines) {
+				result.EndLine = lines[result.EndLine-1]
+			}
+		})
+		started := time.Now()
+		results := processContent(source, file.content, compiledRules, cfg, false, sink)
+		out.recordSource(source, len(file.content), len(results), started)
+		if len(results) == 0 {
+			if !cfg.Quiet && cfg.Verbose {
+				i18n.Printf("文件 '%s' 的新增行中未发现匹配项。\n", source)
+			}
+			continue
+		}
+		outputFilePaths, err := finish(results)
+		if err != nil {
+			i18n.Printf("错误: %v\n", err)
+		} else if !cfg.Quiet && len(outputFilePaths) > 0 {
+			i18n.Printf("发现敏感信息 [%s] -> %s\n", source, strings.Join(outputFilePaths, ", "))
+		}
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	i18n.Printf("git 差异扫描完成。总耗时: %v\n", time.Since(startTime))
+	return nil
+}
+
+// readGitDiff 在仓库目录 dir 中执行 git diff 并解析出每个文件新增的行
+func readGitDiff(dir, diffRange string) ([]diffFile, error) {
+	if strings.HasPrefix(diffRange, "-") {
+		return nil, fmt.Errorf("无效的 git 提交范围 '%s'", diffRange)
+	}
+	// --unified=0 只输出变更的行；--diff-filter=d 排除被删除的文件；不使用外部 diff 和 textconv，保证输出格式可解析
+	cmd := exec.Command("git", "-C", dir, "-c", "core.quotePath=false", "diff",
+		"--no-color", "--no-ext-diff", "--no-textconv", "--unified=0", "--diff-filter=d", diffRange, "--")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("执行 git diff '%s' 失败: %v: %s", diffRange, err, strings.TrimSpace(stderr.String()))
+	}
+	return parseUnifiedDiff(bytes.NewReader(output))
+}
+
+// parseUnifiedDiff 解析 unified diff，返回每个文件新增的行及其在新文件中的行号
+// 二进制文件和没有新增行的文件不会出现在结果中
+func parseUnifiedDiff(r io.Reader) ([]diffFile, error) {
+	var files []diffFile
+	var current *diffFile
+	newLine := 0      // 下一个新增行或上下文行在新文件中的行号
+	inHeader := false // 位于文件头 (diff --git 与第一个 @@ 之间)，此时 "+++ " 开头的行是新文件名而非新增行
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // 压缩后的 JS 等文件可能有很长的行
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			current = nil
+			inHeader = true
+		case inHeader && strings.HasPrefix(line, "+++ "):
+			// 含空格的文件名后面会有一个制表符
+			path := strings.TrimSuffix(strings.TrimPrefix(line, "+++ "), "\t")
+			if path == "/dev/null" {
+				current = nil
+				continue
+			}
+			if unquoted, err := strconv.Unquote(path); err == nil {
+				path = unquoted // 含特殊字符的路径被 git 加上引号并转义
+			}
+			files = append(files, diffFile{path: strings.TrimPrefix(path, "b/")})
+			current = &files[len(files)-1]
+		case strings.HasPrefix(line, "@@ "):
+			start, err := parseHunkStart(line)
+			if err != nil {
+				return nil, err
+			}
+			newLine = start
+			inHeader = false
+			if current != nil && len(current.lines) > 0 {
+				current.content = append(current.content, '\n')
+				current.lines = append(current.lines, 0)
+			}
+		case inHeader:
+			// 文件头中的其他行 (index、mode、--- 旧文件名、Binary files ... differ 等)
+		case current != nil && strings.HasPrefix(line, "+"):
+			current.content = append(current.content, line[1:]...)
+			current.content = append(current.content, '\n')
+			current.lines = append(current.lines, newLine)
+			newLine++
+		case strings.HasPrefix(line, " "):
+			newLine++ // 上下文行 (--unified=0 时不会出现)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("解析 git diff 输出失败: %w", err)
+	}
+
+	kept := files[:0]
+	for _, file := range files {
+		if len(file.content) > 0 {
+			kept = append(kept, file)
+		}
+	}
+	return kept, nil
+}
+
+// parseHunkStart 从 hunk 头 (例如 "@@ -10,2 +12,3 @@") 中取出新文件的起始行号
+func parseHunkStart(header string) (int, error) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return 0, fmt.Errorf("无法解析 hunk 头: %s", header)
+	}
+	start, _, _ := strings.Cut(strings.TrimPrefix(fields[2], "+"), ",")
+	n, err := strconv.Atoi(start)
+	if err != nil {
+		return 0, fmt.Errorf("无法解析 hunk 头: %s", header)
+	}
+	return n, nil
+}
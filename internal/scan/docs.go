@@ -0,0 +1,275 @@
+package scan
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 文档文本提取 (-scan-docs) 的限制，防止恶意或损坏的文档 (例如 zip 炸弹) 耗尽资源
+const (
+	maxDocumentFileSize    = 50 * 1024 * 1024 // 文档文件本身的最大大小
+	maxDocumentTextSize    = 20 * 1024 * 1024 // 提取出的文本的最大大小，超出部分被丢弃
+	documentExtractTimeout = 30 * time.Second // 单个文档提取文本的最长时间
+)
+
+// documentSourceSuffix 附加在文档路径后作为来源标识，表示结果来自提取出的文本 (例如 doc.pdf#text)
+const documentSourceSuffix = "#text"
+
+// documentExtensions 支持提取文本的文档类型
+var documentExtensions = map[string]bool{
+	".pdf":  true,
+	".docx": true,
+	".xlsx": true,
+	".pptx": true,
+}
+
+var errDocumentTimeout = errors.New("提取超时")
+
+// isDocumentFile 判断文件是否为支持提取文本的文档
+func isDocumentFile(filePath string) bool {
+	return documentExtensions[strings.ToLower(filepath.Ext(filePath))]
+}
+
+// extractDocumentText 提取 PDF 或 Office (docx/xlsx/pptx) 文档中的文本
+// 返回的 truncated 表示文本超过 maxDocumentTextSize，只返回了前面的部分
+func extractDocumentText(filePath string) (text []byte, truncated bool, err error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, false, err
+	}
+	if info.Size() > maxDocumentFileSize {
+		return nil, false, fmt.Errorf("文件超过 %dMB 限制", maxDocumentFileSize/(1024*1024))
+	}
+
+	ex := &textExtractor{deadline: time.Now().Add(documentExtractTimeout)}
+	if strings.ToLower(filepath.Ext(filePath)) == ".pdf" {
+		err = ex.extractPDF(filePath)
+	} else {
+		err = ex.extractOffice(filePath)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return ex.buf.Bytes(), ex.truncated, nil
+}
+
+// textExtractor 累积提取出的文本，并检查大小和时间限制
+type textExtractor struct {
+	buf       bytes.Buffer
+	deadline  time.Time
+	truncated bool
+}
+
+// full 返回文本是否已达到大小上限
+func (ex *textExtractor) full() bool {
+	return ex.truncated
+}
+
+func (ex *textExtractor) write(p []byte) {
+	remaining := maxDocumentTextSize - ex.buf.Len()
+	if len(p) > remaining {
+		p = p[:remaining]
+		ex.truncated = true
+	}
+	ex.buf.Write(p)
+}
+
+func (ex *textExtractor) writeByte(c byte) {
+	ex.write([]byte{c})
+}
+
+func (ex *textExtractor) checkDeadline() error {
+	if time.Now().After(ex.deadline) {
+		return errDocumentTimeout
+	}
+	return nil
+}
+
+// officeTextParts 匹配 Office Open XML 压缩包中包含正文文本的部件
+var officeTextParts = regexp.MustCompile(`^(word/(document|header\d*|footer\d*|footnotes|endnotes|comments)\.xml|xl/sharedStrings\.xml|xl/worksheets/sheet\d+\.xml|xl/comments\d*\.xml|ppt/slides/slide\d+\.xml|ppt/notesSlides/notesSlide\d+\.xml|docProps/(core|app|custom)\.xml)$`)
+
+// officeLineElements 结束时换行的 XML 元素 (段落、共享字符串、行)，officeCellElements 结束时插入制表符
+var (
+	officeLineElements = map[string]bool{"p": true, "si": true, "row": true, "br": true}
+	officeCellElements = map[string]bool{"c": true, "tab": true}
+)
+
+// extractOffice 从 docx/xlsx/pptx (zip 格式) 中提取文本
+func (ex *textExtractor) extractOffice(filePath string) error {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return fmt.Errorf("不是有效的 Office 文档: %w", err)
+	}
+	defer archive.Close()
+
+	for _, part := range archive.File {
+		if !officeTextParts.MatchString(path.Clean(part.Name)) {
+			continue
+		}
+		if err := ex.extractOfficePart(part); err != nil {
+			return err
+		}
+		if ex.full() {
+			break
+		}
+	}
+	return nil
+}
+
+func (ex *textExtractor) extractOfficePart(part *zip.File) error {
+	rc, err := part.Open()
+	if err != nil {
+		return fmt.Errorf("读取 '%s' 失败: %w", part.Name, err)
+	}
+	defer rc.Close()
+
+	// 限制解压后读取的 XML 大小，防止 zip 炸弹
+	decoder := xml.NewDecoder(io.LimitReader(rc, maxDocumentFileSize))
+	decoder.Strict = false
+	for {
+		if err := ex.checkDeadline(); err != nil {
+			return err
+		}
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("解析 '%s' 失败: %w", part.Name, err)
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			ex.write(t)
+		case xml.EndElement:
+			if officeLineElements[t.Name.Local] {
+				ex.writeByte('\n')
+			} else if officeCellElements[t.Name.Local] {
+				ex.writeByte('\t')
+			}
+		}
+		if ex.full() {
+			return nil
+		}
+	}
+}
+
+// pdfStreamPattern 匹配 PDF 中的流对象内容
+var pdfStreamPattern = regexp.MustCompile(`(?s)stream\r?\n(.*?)\r?\nendstream`)
+
+// extractPDF 从 PDF 中提取文本：解压 FlateDecode 流，读取文本对象 (BT...ET) 中的字符串
+// 只支持字面量字符串 (...)，使用 CID 字体编码或加密的 PDF 无法提取出可读文本
+func (ex *textExtractor) extractPDF(filePath string) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(content, []byte("%PDF-")) {
+		return fmt.Errorf("不是有效的 PDF 文档")
+	}
+
+	for _, loc := range pdfStreamPattern.FindAllSubmatchIndex(content, -1) {
+		if err := ex.checkDeadline(); err != nil {
+			return err
+		}
+		stream := content[loc[2]:loc[3]]
+		if zr, err := zlib.NewReader(bytes.NewReader(stream)); err == nil {
+			// 解压失败 (非 FlateDecode 流) 时按原始内容处理
+			decompressed, err := io.ReadAll(io.LimitReader(zr, maxDocumentTextSize))
+			zr.Close()
+			if err == nil || len(decompressed) > 0 {
+				stream = decompressed
+			}
+		}
+		if bytes.Contains(stream, []byte("BT")) {
+			ex.extractPDFText(stream)
+		}
+		if ex.full() {
+			break
+		}
+	}
+	return nil
+}
+
+// extractPDFText 从内容流中提取字面量字符串，每个文本对象结束 (ET) 时换行
+func (ex *textExtractor) extractPDFText(stream []byte) {
+	for i := 0; i < len(stream) && !ex.full(); {
+		switch {
+		case stream[i] == '(':
+			var text []byte
+			text, i = parsePDFString(stream, i)
+			ex.write(text)
+		case stream[i] == 'E' && i+1 < len(stream) && stream[i+1] == 'T':
+			ex.writeByte('\n')
+			i += 2
+		default:
+			i++
+		}
+	}
+}
+
+// parsePDFString 解析从 start 处 '(' 开始的 PDF 字面量字符串，返回解码后的内容和字符串结束后的位置
+func parsePDFString(data []byte, start int) ([]byte, int) {
+	var out []byte
+	depth := 0
+	i := start
+	for ; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return out, i + 1
+			}
+		case '\\':
+			i++
+			if i >= len(data) {
+				return out, i
+			}
+			switch e := data[i]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r', '\n':
+				// 行尾续行，忽略
+			default:
+				if e >= '0' && e <= '7' {
+					// 最多 3 位八进制数
+					value := int(e - '0')
+					for n := 0; n < 2 && i+1 < len(data) && data[i+1] >= '0' && data[i+1] <= '7'; n++ {
+						i++
+						value = value*8 + int(data[i]-'0')
+					}
+					out = append(out, byte(value))
+				} else {
+					out = append(out, e) // \( \) \\ 及其他字符
+				}
+			}
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, i
+}
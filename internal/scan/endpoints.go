@@ -0,0 +1,43 @@
+package scan
+
+import "regexp"
+
+// endpointRuleName 是 -endpoints 提取出的端点发现使用的规则名
+const endpointRuleName = "endpoint"
+
+// endpointPatterns 用于从 JS 中提取 URL 和路径的正则 (参考 LinkFinder)，第 1 个捕获组为端点本身
+var endpointPatterns = []*regexp.Regexp{
+	// 完整 URL 或协议相对 URL: "https://api.example.com/v1/user", "//cdn.example.com/a.js"
+	regexp.MustCompile(`["'\x60]((?:[a-zA-Z]{1,10}://|//)[^"'\x60/\s]{1,}\.[a-zA-Z]{2,}[^"'\x60\s]{0,})["'\x60]`),
+	// 绝对路径或相对路径: "/api/v1/users", "../admin/config", "./upload"
+	regexp.MustCompile(`["'\x60]((?:/|\.\./|\./)[^"'\x60><,;| *()%$^/\\\[\]][^"'\x60><,;|()\s]{1,})["'\x60]`),
+	// 带扩展名的相对路径: "api/user/list.action", "static/js/app.js?v=1"
+	regexp.MustCompile(`["'\x60]([a-zA-Z0-9_\-/]{1,}/[a-zA-Z0-9_\-/.]{1,}\.(?:[a-zA-Z]{1,4}|action)(?:[?#][^"'\x60\s]{0,})?)["'\x60]`),
+	// 不带扩展名的 REST 风格相对路径: "api/v1/users"
+	regexp.MustCompile(`["'\x60]([a-zA-Z0-9_\-]{1,}/[a-zA-Z0-9_\-/]{3,}(?:[?#][^"'\x60\s]{0,})?)["'\x60]`),
+	// 常见的服务端文件名: "login.php", "config.json?debug=1"
+	regexp.MustCompile(`["'\x60]([a-zA-Z0-9_\-]{1,}\.(?:php|asp|aspx|jsp|json|action|html|js|txt|xml)(?:[?#][^"'\x60\s]{0,})?)["'\x60]`),
+}
+
+// extractEndpoints 从内容中提取 API 端点、URL 和路径 (-endpoints)，同一来源中相同的端点只报告一次
+func extractEndpoints(source string, content []byte, bounds matchBounds) []ScanResult {
+	var results []ScanResult
+	seen := make(map[string]bool)
+	for _, re := range endpointPatterns {
+		for _, loc := range re.FindAllSubmatchIndex(content, -1) {
+			endpoint, start, ok := bounds.apply(content, loc[2], loc[3])
+			if !ok || seen[endpoint] {
+				continue
+			}
+			seen[endpoint] = true
+			results = append(results, ScanResult{
+				Source:  source,
+				Rule:    endpointRuleName,
+				Pattern: re.String(),
+				Match:   endpoint,
+				Offset:  start,
+			})
+		}
+	}
+	return results
+}
@@ -0,0 +1,115 @@
+package scan
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"jsleaksscan/internal/i18n"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// 浏览器扩展包扫描 (-scan-extensions) 的限制，防止恶意的压缩包 (例如 zip 炸弹) 耗尽资源
+const (
+	maxExtensionFileSize  = 100 * 1024 * 1024 // 扩展包文件本身的最大大小
+	maxExtensionEntrySize = 20 * 1024 * 1024  // 单个文件解压后的最大处理大小
+	maxExtensionTotalSize = 200 * 1024 * 1024 // 一个扩展包内所有文件解压后的总大小上限
+)
+
+// extensionEntrySeparator 分隔扩展包路径和包内文件名的来源标识 (例如 ext.crx!background.js)
+const extensionEntrySeparator = "!"
+
+// crxMagic 是 Chrome 扩展包 (CRX) 头部的魔数
+var crxMagic = []byte("Cr24")
+
+// isExtensionPackage 判断文件是否为 Chrome (.crx) 或 Firefox (.xpi) 扩展包
+func isExtensionPackage(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	return ext == ".crx" || ext == ".xpi"
+}
+
+// extensionZipOffset 返回扩展包中 zip 数据的起始偏移
+// XPI 是普通 zip 文件，偏移为 0；CRX 在 zip 数据前有一个头部：
+//   - CRX2: "Cr24" + 版本 + 公钥长度 + 签名长度 (各 4 字节，小端)，之后是公钥和签名
+//   - CRX3: "Cr24" + 版本 + 头部长度 (各 4 字节，小端)，之后是 protobuf 格式的头部
+func extensionZipOffset(data []byte) (int64, error) {
+	if !bytes.HasPrefix(data, crxMagic) {
+		return 0, nil
+	}
+	if len(data) < 12 {
+		return 0, fmt.Errorf("CRX 头部不完整")
+	}
+	var offset uint64
+	switch version := binary.LittleEndian.Uint32(data[4:8]); version {
+	case 2:
+		if len(data) < 16 {
+			return 0, fmt.Errorf("CRX 头部不完整")
+		}
+		offset = 16 + uint64(binary.LittleEndian.Uint32(data[8:12])) + uint64(binary.LittleEndian.Uint32(data[12:16]))
+	case 3:
+		offset = 12 + uint64(binary.LittleEndian.Uint32(data[8:12]))
+	default:
+		return 0, fmt.Errorf("不支持的 CRX 版本 %d", version)
+	}
+	if offset > uint64(len(data)) {
+		return 0, fmt.Errorf("CRX 头部长度超出文件大小")
+	}
+	return int64(offset), nil
+}
+
+// scanExtensionPackage 解开 .crx/.xpi 扩展包，逐个扫描其中的脚本和文本文件 (按 jsExtensions 判断)，
+// 来源标识为 "<扩展包路径>!<包内路径>"
+func scanExtensionPackage(filePath string, scan func(source string, content []byte)) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+	if info.Size() > maxExtensionFileSize {
+		return fmt.Errorf("文件超过 %dMB 限制", maxExtensionFileSize/(1024*1024))
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	offset, err := extensionZipOffset(data)
+	if err != nil {
+		return err
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data[offset:]), int64(len(data))-offset)
+	if err != nil {
+		return fmt.Errorf("不是有效的扩展包: %w", err)
+	}
+
+	var total int64
+	for _, entry := range archive.File {
+		name := path.Clean(entry.Name)
+		if entry.FileInfo().IsDir() || !jsExtensions[strings.ToLower(path.Ext(name))] {
+			continue
+		}
+		if total >= maxExtensionTotalSize {
+			return fmt.Errorf("解压后的总大小超过 %dMB 限制，其余文件未扫描", maxExtensionTotalSize/(1024*1024))
+		}
+		content, err := readExtensionEntry(entry, min(maxExtensionEntrySize, maxExtensionTotalSize-total))
+		if err != nil {
+			i18n.Printf("警告: 读取扩展包 '%s' 中的 '%s' 失败: %v\n", filePath, name, err)
+			continue
+		}
+		total += int64(len(content))
+		scan(filePath+extensionEntrySeparator+name, content)
+	}
+	return nil
+}
+
+// readExtensionEntry 读取压缩包中的单个文件，最多读取 limit 字节
+func readExtensionEntry(entry *zip.File, limit int64) ([]byte, error) {
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, limit))
+}
@@ -0,0 +1,79 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// externalMatcherTimeout 单次运行外部匹配程序的最长时间
+const externalMatcherTimeout = 60 * time.Second
+
+// externalRuleName 外部匹配程序未给出规则名时使用的默认规则名
+const externalRuleName = "external"
+
+// runExternalMatcher 对单个来源运行外部匹配程序 (-matcher)，并解析其输出的发现
+//
+// 协议：
+//   - 来源内容通过 stdin 传入，来源标识 (文件路径或 URL) 通过环境变量 JSLEAKS_SOURCE 传入
+//   - 程序在 stdout 中每行输出一条发现，格式为 "规则名<TAB>匹配内容"；没有 TAB 的行视为
+//     规则名为 "external" 的匹配内容；空行被忽略
+//   - 非零退出码视为执行失败
+func runExternalMatcher(command string, source string, content []byte) ([]ScanResult, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), externalMatcherTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Env = append(os.Environ(), "JSLEAKS_SOURCE="+source)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("外部匹配程序处理 '%s' 超时 (%v)", source, externalMatcherTimeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("外部匹配程序处理 '%s' 失败: %w (stderr: %s)", source, err, msg)
+		}
+		return nil, fmt.Errorf("外部匹配程序处理 '%s' 失败: %w", source, err)
+	}
+
+	var results []ScanResult
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024) // 允许较长的匹配行
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		rule, match, found := strings.Cut(line, "\t")
+		if !found {
+			rule, match = externalRuleName, line
+		}
+		offset := bytes.Index(content, []byte(match)) // 用于计算行号，找不到时按首行处理
+		if offset < 0 {
+			offset = 0
+		}
+		results = append(results, ScanResult{
+			Source: source,
+			Rule:   rule,
+			Match:  match,
+			Offset: offset,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("解析外部匹配程序输出失败: %w", err)
+	}
+	return results, nil
+}
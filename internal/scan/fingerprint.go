@@ -0,0 +1,53 @@
+package scan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// fingerprintVersion 是指纹算法的版本前缀，规范化规则变化时递增，避免新旧指纹混淆
+const fingerprintVersion = "v1"
+
+// findingFingerprint 计算发现的稳定指纹，用于在多次扫描之间对同一发现去重
+//
+// 指纹为 sha256("v1" NUL 规范化来源 NUL 规则名 NUL 规范化匹配内容) 的前 16 字节 (32 个十六进制字符)，
+// 不包含行号、偏移、发现时间和 HTTP 状态等易变信息，因此文件内容移动后指纹保持不变。规范化规则：
+//   - URL 来源 (http/https)：协议和主机名转为小写，去掉默认端口、查询参数和片段，路径为空时视为 "/"
+//   - 本地来源：清理路径 (去掉多余的 "."、".." 和分隔符) 并统一使用 "/" 作为分隔符
+//   - 匹配内容：去掉首尾空白
+func findingFingerprint(source, rule, match string) string {
+	h := sha256.New()
+	for i, part := range []string{fingerprintVersion, normalizeFingerprintSource(source), rule, strings.TrimSpace(match)} {
+		if i > 0 {
+			h.Write([]byte{0})
+		}
+		h.Write([]byte(part))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// normalizeFingerprintSource 按 findingFingerprint 中描述的规则规范化来源
+func normalizeFingerprintSource(source string) string {
+	if u, err := url.Parse(source); err == nil && (strings.EqualFold(u.Scheme, "http") || strings.EqualFold(u.Scheme, "https")) && u.Host != "" {
+		scheme := strings.ToLower(u.Scheme)
+		host := strings.ToLower(u.Hostname())
+		if port := u.Port(); port != "" && !(scheme == "http" && port == "80") && !(scheme == "https" && port == "443") {
+			host = net.JoinHostPort(host, port) // IPv6 地址加方括号，避免与端口混淆
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		p := u.EscapedPath()
+		if p == "" {
+			p = "/"
+		} else {
+			p = path.Clean(p)
+		}
+		return scheme + "://" + host + p
+	}
+	return filepath.ToSlash(filepath.Clean(source))
+}
@@ -0,0 +1,49 @@
+package scan
+
+import (
+	"net/url"
+	"strings"
+)
+
+// expandFuzzPaths 为输入 URL 中出现的每个主机拼接字典中的路径 (-fuzz-paths)，返回追加了候选 URL 的列表
+// 候选 URL 只在输入 URL 已有的主机 (协议 + 主机 + 端口) 上生成，不会扩展到其他主机；
+// 与已有 URL 或其他候选重复的 URL 只保留一个。字典中的空行和以 # 开头的行被忽略
+func expandFuzzPaths(urls []string, wordlist []string) (expanded []string, generated int) {
+	seen := make(map[string]bool, len(urls))
+	var bases []string
+	seenBases := make(map[string]bool)
+	for _, raw := range urls {
+		seen[raw] = true
+		target, _ := normalizeTargetURL(raw) // 与 processURL 一致，缺少协议时默认 HTTPS
+		u, err := url.Parse(target)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		base := u.Scheme + "://" + u.Host
+		if !seenBases[base] {
+			seenBases[base] = true
+			bases = append(bases, base)
+		}
+	}
+
+	expanded = urls
+	for _, base := range bases {
+		for _, word := range wordlist {
+			word = strings.TrimSpace(word)
+			if word == "" || strings.HasPrefix(word, "#") {
+				continue
+			}
+			if !strings.HasPrefix(word, "/") {
+				word = "/" + word
+			}
+			candidate := base + word
+			if seen[candidate] {
+				continue
+			}
+			seen[candidate] = true
+			expanded = append(expanded, candidate)
+			generated++
+		}
+	}
+	return expanded, generated
+}
@@ -0,0 +1,86 @@
+package scan
+
+import (
+	"jsleaksscan/internal/config"
+	"jsleaksscan/internal/httpclient"
+	"jsleaksscan/internal/i18n"
+	"jsleaksscan/internal/rules"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HAR 记录中各部分的来源标识后缀，URL 本身 (含查询字符串) 以请求 URL 作为来源
+const (
+	harRequestSuffix  = "#request"  // 请求体
+	harResponseSuffix = "#response" // 响应体
+)
+
+// scanHARInput 离线扫描 HAR 文件 (-har-input) 中记录的流量：对每条记录的请求 URL、请求体和响应体分别匹配规则，
+// 不发送任何请求。结果按请求 URL 归类，来源标识分别为 "<URL>"、"<URL>#request" 和 "<URL>#response"
+func scanHARInput(cfg *config.AppConfig, compiledRules *rules.CompiledRules, startTime time.Time) error {
+	entries, err := httpclient.ReadHAR(cfg.HARInput)
+	if err != nil {
+		return err
+	}
+	i18n.Printf("开始扫描 HAR 文件: %s (%d 条记录, 并发度: %d)\n", cfg.HARInput, len(entries), cfg.ThreadNum)
+
+	out, err := newResultWriter(cfg, compiledRules)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	var wg sync.WaitGroup
+	entryQueue := make(chan httpclient.HAREntry, cfg.ThreadNum*2)
+	for i := 0; i < cfg.ThreadNum; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range entryQueue {
+				processHAREntry(entry, cfg, compiledRules, out)
+			}
+		}()
+	}
+	for _, entry := range entries {
+		entryQueue <- entry
+	}
+	close(entryQueue)
+	wg.Wait()
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+	i18n.Printf("HAR 文件扫描完成。总耗时: %v\n", time.Since(startTime))
+	return nil
+}
+
+// harEntryKey 去掉来源标识中的请求体/响应体后缀，返回请求 URL
+func harEntryKey(source string) string {
+	if key, ok := strings.CutSuffix(source, harRequestSuffix); ok {
+		return key
+	}
+	return strings.TrimSuffix(source, harResponseSuffix)
+}
+
+// processHAREntry 扫描一条 HAR 记录的 URL、请求体和响应体
+func processHAREntry(entry httpclient.HAREntry, cfg *config.AppConfig, compiledRules *rules.CompiledRules, out *resultWriter) {
+	if !cfg.Quiet && cfg.Verbose {
+		i18n.Printf("扫描 HAR 记录: %s %s (状态码: %d)\n", entry.Method, entry.URL, entry.Status)
+	}
+
+	// 查询字符串中的值可能经过百分号编码，解码后的 URL 也一并匹配
+	target := []byte(entry.URL)
+	if decoded, err := url.QueryUnescape(entry.URL); err == nil && decoded != entry.URL {
+		target = append(append(target, '\n'), decoded...)
+	}
+	processLocalContent(entry.URL, target, cfg, compiledRules, out)
+	// 没有请求体 (如 GET) 或导出时未包含响应体的记录直接跳过，不按本地空文件提示
+	if len(entry.RequestBody) > 0 {
+		processLocalContent(entry.URL+harRequestSuffix, entry.RequestBody, cfg, compiledRules, out)
+	}
+	if len(entry.ResponseBody) > 0 {
+		processLocalContent(entry.URL+harResponseSuffix, entry.ResponseBody, cfg, compiledRules, out)
+	}
+}
@@ -0,0 +1,166 @@
+package scan
+
+import (
+	"jsleaksscan/internal/config"
+	"jsleaksscan/internal/i18n"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// importSourceExtensions 是 -follow-imports 解析 import/require 语句的源码扩展名
+var importSourceExtensions = map[string]bool{
+	".js": true, ".jsx": true, ".mjs": true, ".cjs": true, ".ts": true, ".tsx": true, ".vue": true, ".svelte": true,
+}
+
+// importResolveExtensions 是解析省略扩展名的导入路径时依次尝试的扩展名 (与常见打包工具的默认顺序一致)
+var importResolveExtensions = []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", ".json", ".vue", ".svelte"}
+
+// importPatterns 匹配 ES 模块的 import/export ... from、副作用导入 import 'x'、动态 import('x') 和 CommonJS 的 require('x')，
+// 第一个捕获组为模块路径
+var importPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b(?:import|export)\s[^;'"` + "`" + `]*?\bfrom\s*['"]([^'"\n]+)['"]`),
+	regexp.MustCompile(`\bimport\s*['"]([^'"\n]+)['"]`),
+	regexp.MustCompile(`\b(?:import|require)\s*\(\s*['"]([^'"\n]+)['"]\s*\)`),
+}
+
+// importSpecifiers 返回 JS/TS 源码中以 ./ 或 ../ 开头的相对导入路径 (按出现顺序，已去重)；不是 JS/TS 来源时返回 nil。
+// 包名 (如 "react") 和绝对路径不在本地项目的相对位置，不返回
+func importSpecifiers(source string, content []byte) []string {
+	if !importSourceExtensions[sourceExt(source)] {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var specs []string
+	for _, pattern := range importPatterns {
+		for _, match := range pattern.FindAllSubmatch(content, -1) {
+			spec := string(match[1])
+			if spec != "." && spec != ".." && !strings.HasPrefix(spec, "./") && !strings.HasPrefix(spec, "../") {
+				continue
+			}
+			if !seen[spec] {
+				seen[spec] = true
+				specs = append(specs, spec)
+			}
+		}
+	}
+	return specs
+}
+
+// importFollower 在本地扫描中跟随相对导入 (-follow-imports)，使被导入的本地模块即使不符合扩展名和 MIME 筛选条件也被扫描。
+// 所有 worker 共享已扫描文件的集合 (按绝对路径)，遍历目录得到的文件和通过导入发现的文件都只扫描一次
+type importFollower struct {
+	root          string    // 只跟随 root 目录内的文件 (绝对路径)
+	modifiedSince time.Time // 与目录遍历相同的增量扫描条件
+	verbose       bool
+
+	mu       sync.Mutex
+	scanned  map[string]bool
+	followed int // 通过导入发现并扫描的文件数
+}
+
+// newImportFollower 在启用 -follow-imports 时创建 importFollower，root 为 -d 指定的目录；未启用时返回 nil
+func newImportFollower(cfg *config.AppConfig, root string, modifiedSince time.Time) *importFollower {
+	if !cfg.FollowImports {
+		return nil
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return &importFollower{root: root, modifiedSince: modifiedSince, verbose: !cfg.Quiet && cfg.Verbose, scanned: make(map[string]bool)}
+}
+
+// claim 将文件标记为已扫描，文件已被其他 worker 扫描过时返回 false
+func (f *importFollower) claim(path string) bool {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.scanned[path] {
+		return false
+	}
+	f.scanned[path] = true
+	return true
+}
+
+// process 扫描文件并依次扫描它导入的本地模块 (深度优先，在当前 worker 中完成)。
+// scanFile 扫描单个文件并返回其中的相对导入路径
+func (f *importFollower) process(filePath string, scanFile func(path string) []string) {
+	if !f.claim(filePath) {
+		return
+	}
+	stack := []string{filePath}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, spec := range scanFile(current) {
+			resolved := f.resolve(current, spec)
+			if resolved == "" || !f.claim(resolved) {
+				continue
+			}
+			if f.verbose {
+				i18n.Printf("跟随 '%s' 中的导入 '%s' 扫描: %s\n", current, spec, resolved)
+			}
+			f.mu.Lock()
+			f.followed++
+			f.mu.Unlock()
+			stack = append(stack, resolved)
+		}
+	}
+}
+
+// resolve 按打包工具的规则将 from 中的相对导入路径解析为文件: 原路径、补全扩展名、目录下的 index 文件，
+// TypeScript 源码中以 .js 结尾的导入也尝试对应的 .ts/.tsx 文件。
+// 解析不到文件、文件在 root 之外或不满足增量扫描条件时返回空字符串
+func (f *importFollower) resolve(from, spec string) string {
+	// 去掉打包工具的查询参数和片段 (如 ./logo.svg?raw)
+	if i := strings.IndexAny(spec, "?#"); i >= 0 {
+		spec = spec[:i]
+	}
+	base := filepath.Join(filepath.Dir(from), filepath.FromSlash(spec))
+
+	candidates := []string{base}
+	for _, ext := range importResolveExtensions {
+		candidates = append(candidates, base+ext)
+	}
+	if ext := filepath.Ext(base); ext == ".js" || ext == ".jsx" {
+		trimmed := strings.TrimSuffix(base, ext)
+		candidates = append(candidates, trimmed+".ts", trimmed+".tsx")
+	}
+	for _, ext := range importResolveExtensions {
+		candidates = append(candidates, filepath.Join(base, "index"+ext))
+	}
+
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if !f.inRoot(candidate) || (!f.modifiedSince.IsZero() && info.ModTime().Before(f.modifiedSince)) {
+			return ""
+		}
+		return candidate
+	}
+	return ""
+}
+
+// inRoot 判断文件是否在 -d 指定的目录内
+func (f *importFollower) inRoot(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(f.root, abs)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// followedCount 返回通过导入发现并扫描的文件数
+func (f *importFollower) followedCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.followed
+}
@@ -0,0 +1,145 @@
+package scan
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// resultIndexFile 是 -index 在输出目录中写入的结果索引文件名
+const resultIndexFile = "index.json"
+
+// indexFileEntry 是一个来源 (或主机) 写入的一个结果文件
+type indexFileEntry struct {
+	Path     string `json:"path"` // 相对于输出目录的路径 (使用 / 分隔)
+	Findings int    `json:"findings"`
+}
+
+// indexSourceEntry 是结果索引中的一个来源
+type indexSourceEntry struct {
+	Source   string           `json:"source"`
+	Host     string           `json:"host,omitempty"` // URL 来源的主机名，本地文件为空
+	Findings int              `json:"findings"`
+	Files    []indexFileEntry `json:"files"`
+}
+
+// indexHostEntry 是结果索引中按主机汇总的 URL 来源
+type indexHostEntry struct {
+	Host     string           `json:"host"`
+	Sources  int              `json:"sources"`
+	Findings int              `json:"findings"`
+	Files    []indexFileEntry `json:"files"`
+}
+
+// indexFile 是 -index 写入的 JSON 文件
+type indexFile struct {
+	RunID         string             `json:"run_id"`
+	Mode          string             `json:"mode"`
+	OutputDir     string             `json:"output_dir"`
+	GeneratedAt   time.Time          `json:"generated_at"`
+	TotalFindings int                `json:"total_findings"`
+	Hosts         []indexHostEntry   `json:"hosts"`
+	Sources       []indexSourceEntry `json:"sources"`
+}
+
+// resultIndex 记录每个来源的发现写入了哪些结果文件，扫描结束时写入结果索引 (-index)，
+// 便于程序按来源或主机找到结果文件，而不必遍历 (可能分片或按目录结构镜像的) 输出目录。
+// 与覆盖清单 (-manifest) 不同，只包含有发现写入的来源。可被多个 goroutine 并发使用
+type resultIndex struct {
+	mu      sync.Mutex
+	outDir  string
+	sources map[string]map[string]int // 来源 -> 结果文件路径 -> 写入的发现数
+}
+
+func newResultIndex(outDir string) *resultIndex {
+	return &resultIndex{outDir: outDir, sources: make(map[string]map[string]int)}
+}
+
+// record 记录写入结果文件 path 的一批发现 (同一文件中可能有多个来源的发现)
+func (idx *resultIndex) record(path string, results []ScanResult) {
+	if rel, err := filepath.Rel(idx.outDir, path); err == nil {
+		path = rel
+	}
+	path = filepath.ToSlash(path)
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, result := range results {
+		files, ok := idx.sources[result.Source]
+		if !ok {
+			files = make(map[string]int)
+			idx.sources[result.Source] = files
+		}
+		files[path]++
+	}
+}
+
+// write 按来源和主机排序后将索引写入 path，返回索引中的来源数
+func (idx *resultIndex) write(path, runID, mode string) (int, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	index := indexFile{
+		RunID:       runID,
+		Mode:        mode,
+		OutputDir:   idx.outDir,
+		GeneratedAt: time.Now().UTC(),
+		Hosts:       []indexHostEntry{},
+		Sources:     make([]indexSourceEntry, 0, len(idx.sources)),
+	}
+	hosts := make(map[string]*indexHostEntry)
+	hostFiles := make(map[string]map[string]int)
+	for source, files := range idx.sources {
+		entry := indexSourceEntry{Source: source, Host: sourceHost(source), Files: sortedIndexFiles(files)}
+		for _, file := range entry.Files {
+			entry.Findings += file.Findings
+		}
+		index.Sources = append(index.Sources, entry)
+		index.TotalFindings += entry.Findings
+		if entry.Host == "" {
+			continue
+		}
+		host, ok := hosts[entry.Host]
+		if !ok {
+			host = &indexHostEntry{Host: entry.Host}
+			hosts[entry.Host] = host
+			hostFiles[entry.Host] = make(map[string]int)
+		}
+		host.Sources++
+		host.Findings += entry.Findings
+		for file, count := range files {
+			hostFiles[entry.Host][file] += count
+		}
+	}
+	for name, host := range hosts {
+		host.Files = sortedIndexFiles(hostFiles[name])
+		index.Hosts = append(index.Hosts, *host)
+	}
+	slices.SortFunc(index.Sources, func(a, b indexSourceEntry) int { return cmp.Compare(a.Source, b.Source) })
+	slices.SortFunc(index.Hosts, func(a, b indexHostEntry) int { return cmp.Compare(a.Host, b.Host) })
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("序列化结果索引失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, fmt.Errorf("创建结果索引目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return 0, fmt.Errorf("写入结果索引 '%s' 失败: %w", path, err)
+	}
+	return len(index.Sources), nil
+}
+
+// sortedIndexFiles 将 结果文件 -> 发现数 转换为按路径排序的列表
+func sortedIndexFiles(files map[string]int) []indexFileEntry {
+	entries := make([]indexFileEntry, 0, len(files))
+	for path, count := range files {
+		entries = append(entries, indexFileEntry{Path: path, Findings: count})
+	}
+	slices.SortFunc(entries, func(a, b indexFileEntry) int { return cmp.Compare(a.Path, b.Path) })
+	return entries
+}
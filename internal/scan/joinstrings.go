@@ -0,0 +1,168 @@
+package scan
+
+import (
+	"bytes"
+)
+
+// joinStringExtensions 是 -join-strings 处理的 JS/TS 源码扩展名
+var joinStringExtensions = map[string]bool{
+	".js": true, ".jsx": true, ".mjs": true, ".cjs": true, ".ts": true, ".tsx": true,
+}
+
+// stringPart 是字符串拼接中的一个字面量: content[start:end] 含引号
+type stringPart struct {
+	start, end int
+}
+
+// joinStrings 将 JS/TS 源码中相邻字符串字面量的拼接 ("AKIA" + "IOSF" + 'ODNN') 合并为一个字面量 ("AKIAIOSFODNN")，
+// 使被拆开以躲避检测的密钥可以被规则匹配。返回处理后的内容和合并的拼接表达式数量；不是 JS/TS 来源时原样返回。
+//
+// 合并后的字面量写在原表达式的起始位置，剩余部分用空格填充，原表达式中的换行移到合并后的字面量之后，
+// 因此内容长度和换行数不变，表达式之后的匹配偏移和行号与原始内容一致。
+// 只合并不含 ${} 插值的模板字符串；注释和正则字面量 (如 /["]/g) 中的引号不会被当作字符串
+func joinStrings(source string, content []byte) ([]byte, int) {
+	if !joinStringExtensions[sourceExt(source)] {
+		return content, 0
+	}
+
+	out := bytes.Clone(content)
+	joined := 0
+	n := len(content)
+	for i := 0; i < n; {
+		rest := content[i:]
+		switch {
+		case bytes.HasPrefix(rest, []byte("/*")):
+			end := bytes.Index(content[i+2:], []byte("*/"))
+			if end < 0 {
+				return out, joined
+			}
+			i += 2 + end + 2
+		case bytes.HasPrefix(rest, []byte("//")):
+			end := bytes.IndexByte(rest, '\n')
+			if end < 0 {
+				return out, joined
+			}
+			i += end
+		case content[i] == '/' && regexAllowedAt(content, i):
+			i = skipRegexLiteral(content, i)
+		case content[i] == '"' || content[i] == '\'' || content[i] == '`':
+			parts := concatenatedStrings(content, i)
+			if len(parts) >= 2 {
+				writeJoinedString(out, content, parts)
+				joined++
+			}
+			i = parts[len(parts)-1].end
+		default:
+			i++
+		}
+	}
+	return out, joined
+}
+
+// regexAllowedAt 按前一个非空白字符判断 i 处的 / 是否开始一个正则字面量 (而非除号):
+// 位于行首，或跟在 ( , = : [ ! & | ? { ; 等不能结束一个操作数的符号之后
+func regexAllowedAt(content []byte, i int) bool {
+	for j := i - 1; j >= 0; j-- {
+		switch c := content[j]; c {
+		case ' ', '\t', '\r':
+			continue
+		case '\n':
+			return true
+		default:
+			return bytes.IndexByte([]byte("(,=:[!&|?{};"), c) >= 0
+		}
+	}
+	return true
+}
+
+// skipRegexLiteral 跳过从 start 开始的正则字面量 (含标志)，返回其结束后的位置；
+// 字符类 [...] 中的 / 不结束正则。在行尾前没有结束的 / 说明不是正则，此时只跳过 start 处的字符
+func skipRegexLiteral(content []byte, start int) int {
+	inClass := false
+	for i := start + 1; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++ // 跳过转义字符
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '\n':
+			return start + 1
+		case '/':
+			if inClass {
+				continue
+			}
+			i++
+			for i < len(content) && (content[i] >= 'a' && content[i] <= 'z') {
+				i++
+			}
+			return i
+		}
+	}
+	return start + 1
+}
+
+// concatenatedStrings 从 start 处的字符串字面量开始，收集以 + 连接的后续字符串字面量 (允许空白和换行)
+// 返回的第一项总是 start 处的字面量 (可能未闭合)
+func concatenatedStrings(content []byte, start int) []stringPart {
+	first := stringPart{start: start, end: skipString(content, start, false)}
+	parts := []stringPart{first}
+	if !simpleStringLiteral(content, first) {
+		return parts
+	}
+	for {
+		next := skipSpace(content, parts[len(parts)-1].end)
+		// "+" 之后必须是另一个字符串字面量，排除 ++ 和 +=
+		if next >= len(content) || content[next] != '+' || (next+1 < len(content) && (content[next+1] == '+' || content[next+1] == '=')) {
+			return parts
+		}
+		next = skipSpace(content, next+1)
+		if next >= len(content) || (content[next] != '"' && content[next] != '\'' && content[next] != '`') {
+			return parts
+		}
+		part := stringPart{start: next, end: skipString(content, next, false)}
+		if !simpleStringLiteral(content, part) {
+			return parts
+		}
+		parts = append(parts, part)
+	}
+}
+
+// simpleStringLiteral 判断字面量是否已闭合，且 (对模板字符串而言) 不含 ${} 插值
+func simpleStringLiteral(content []byte, part stringPart) bool {
+	quote := content[part.start]
+	if part.end-part.start < 2 || content[part.end-1] != quote {
+		return false
+	}
+	return quote != '`' || !bytes.Contains(content[part.start:part.end], []byte("${"))
+}
+
+func skipSpace(content []byte, i int) int {
+	for i < len(content) && (content[i] == ' ' || content[i] == '\t' || content[i] == '\n' || content[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// writeJoinedString 将合并后的字面量 (使用第一个字面量的引号) 写入 out 中原表达式的位置，
+// 剩余部分用空格填充，并在末尾补回表达式中被移除的换行
+func writeJoinedString(out, content []byte, parts []stringPart) {
+	start, end := parts[0].start, parts[len(parts)-1].end
+	quote := content[start]
+	literal := []byte{quote}
+	for _, part := range parts {
+		literal = append(literal, content[part.start+1:part.end-1]...)
+	}
+	literal = append(literal, quote)
+
+	removedNewlines := bytes.Count(content[start:end], []byte("\n")) - bytes.Count(literal, []byte("\n"))
+	region := out[start:end]
+	copy(region, literal)
+	for j := len(literal); j < len(region); j++ {
+		region[j] = ' '
+	}
+	for j := 0; j < removedNewlines; j++ {
+		region[len(region)-1-j] = '\n'
+	}
+}
@@ -0,0 +1,152 @@
+package scan
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// -jwt 检测到的 JWT 使用的规则名: 无签名 (alg 为 none) 和已过期的令牌使用单独的规则名，便于区分和过滤。
+// 不使用内置规则的 "jwt"，避免内置检测的发现被当作该规则的匹配 (套用其 confirm/deny、严重级别和置信度)
+const (
+	jwtRuleName        = "jwt-decoded"
+	jwtAlgNoneRuleName = "jwt-alg-none"
+	jwtExpiredRuleName = "jwt-expired"
+)
+
+// jwtPattern 匹配 JWT 形式的字符串: base64url 编码的 JSON 头部和载荷 (均以 "ey" 开头，即 "{" 的编码)，签名可以为空 (alg 为 none)
+var jwtPattern = regexp.MustCompile(`\bey[0-9A-Za-z_-]{10,}\.ey[0-9A-Za-z_-]{10,}\.[0-9A-Za-z_-]*`)
+
+// JWTClaims 是从 JWT 头部和载荷中解码出的信息 (-jwt)
+type JWTClaims struct {
+	Alg     string     `json:"alg"`
+	Iss     string     `json:"iss,omitempty"`
+	Exp     *time.Time `json:"exp,omitempty"` // 过期时间 (UTC)，载荷中没有 exp 时为 nil
+	Expired bool       `json:"expired"`       // 发现时令牌是否已过期
+}
+
+// Unsigned 判断令牌是否未签名 (alg 为 none，服务端接受时可被任意伪造)
+func (c *JWTClaims) Unsigned() bool {
+	return strings.EqualFold(c.Alg, "none")
+}
+
+// String 返回文本结果中附加的摘要，例如 "alg=HS256 iss=https://auth.example.com exp=2024-05-01T08:00:00Z 已过期"
+func (c *JWTClaims) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "alg=%s", c.Alg)
+	if c.Unsigned() {
+		b.WriteString(" 未签名")
+	}
+	if c.Iss != "" {
+		fmt.Fprintf(&b, " iss=%s", c.Iss)
+	}
+	if c.Exp != nil {
+		fmt.Fprintf(&b, " exp=%s", c.Exp.Format(time.RFC3339))
+		if c.Expired {
+			b.WriteString(" 已过期")
+		}
+	}
+	return b.String()
+}
+
+// decodeJWT 解码 JWT 的头部和载荷，头部不是含 alg 的 JSON 对象或载荷不是 JSON 对象时返回 nil (不是 JWT)
+// now 为判断是否过期的参考时间
+func decodeJWT(token string, now time.Time) *JWTClaims {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	var header struct {
+		Alg *string `json:"alg"`
+	}
+	if !decodeJWTSegment(parts[0], &header) || header.Alg == nil {
+		return nil
+	}
+	var payload struct {
+		Iss any             `json:"iss"`
+		Exp json.RawMessage `json:"exp"`
+	}
+	if !decodeJWTSegment(parts[1], &payload) {
+		return nil
+	}
+
+	claims := &JWTClaims{Alg: *header.Alg}
+	if iss, ok := payload.Iss.(string); ok {
+		claims.Iss = iss
+	}
+	var exp float64
+	if len(payload.Exp) > 0 && json.Unmarshal(payload.Exp, &exp) == nil && exp > 0 {
+		expAt := time.Unix(int64(exp), 0).UTC()
+		claims.Exp = &expAt
+		claims.Expired = expAt.Before(now)
+	}
+	return claims
+}
+
+// decodeJWTSegment 按 base64url (容忍填充) 解码 JWT 的一段并解析为 JSON 对象
+func decodeJWTSegment(segment string, v any) bool {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// annotateJWT 为匹配内容中包含 JWT 的发现附加解码出的信息，返回匹配内容中是否包含有效的 JWT
+func annotateJWT(result *ScanResult, now time.Time) bool {
+	for _, token := range jwtPattern.FindAllString(result.Match, -1) {
+		if claims := decodeJWT(token, now); claims != nil {
+			result.JWT = claims
+			return true
+		}
+	}
+	return false
+}
+
+// detectJWTs 查找内容中的 JWT 并解码 (-jwt)，已包含在其他规则的匹配中的令牌不再重复报告；
+// 无签名和已过期的令牌分别以 jwt-alg-none、jwt-expired 规则报告，并带有默认的严重级别
+func detectJWTs(source string, content []byte, bounds matchBounds, reported []ScanResult, now time.Time) []ScanResult {
+	var results []ScanResult
+	seen := make(map[string]bool)
+	for _, loc := range jwtPattern.FindAllIndex(content, -1) {
+		token, start, ok := bounds.apply(content, loc[0], loc[1])
+		if !ok || seen[token] {
+			continue
+		}
+		seen[token] = true
+		claims := decodeJWT(token, now)
+		if claims == nil || jwtReported(reported, token) {
+			continue
+		}
+		rule, severity := jwtRuleName, "medium"
+		switch {
+		case claims.Unsigned():
+			rule, severity = jwtAlgNoneRuleName, "high"
+		case claims.Expired:
+			rule, severity = jwtExpiredRuleName, "low"
+		}
+		results = append(results, ScanResult{
+			Source:   source,
+			Rule:     rule,
+			Pattern:  jwtPattern.String(),
+			Match:    token,
+			Severity: severity,
+			Offset:   start,
+			JWT:      claims,
+		})
+	}
+	return results
+}
+
+// jwtReported 判断令牌是否已包含在同一来源的其他发现中
+func jwtReported(reported []ScanResult, token string) bool {
+	for _, result := range reported {
+		if result.JWT != nil && strings.Contains(result.Match, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package scan
+
+import (
+	"bytes"
+	"strings"
+)
+
+// keyContextExtensions 是 -key-context 查找匹配所属键名的来源扩展名 (JS/TS 源码和 JSON)
+var keyContextExtensions = map[string]bool{
+	".js": true, ".jsx": true, ".mjs": true, ".cjs": true, ".ts": true, ".tsx": true, ".vue": true, ".svelte": true,
+	".json": true, ".jsonc": true, ".map": true,
+}
+
+// maxKeyContextScan 是从匹配位置向前查找键名的最大字节数，避免在压缩成一行的超长源码中无界回溯
+const maxKeyContextScan = 256
+
+// isKeyContextSource 判断来源是否为需要查找键名的 JS/JSON 来源
+func isKeyContextSource(source string) bool {
+	return keyContextExtensions[sourceExt(source)]
+}
+
+// enclosingKey 从匹配的偏移 offset 向前查找匹配所赋给的变量、对象键或函数调用，例如:
+//
+//	apiKey: "sk_live_..."         -> apiKey
+//	"client_secret": "..."        -> client_secret
+//	const token = '...'           -> token
+//	this.config.secret = "..."    -> this.config.secret
+//	headers["X-Api-Key"] = "..."  -> X-Api-Key
+//	setToken("...")               -> setToken()
+//
+// 只做轻量的词法回溯 (跳过开头的引号和空白，识别 : = ( 之前的标识符或字符串)，不解析完整语法；找不到时返回空字符串
+func enclosingKey(content []byte, offset int) string {
+	if offset <= 0 || offset > len(content) {
+		return ""
+	}
+	lower := max(0, offset-maxKeyContextScan)
+	i := offset - 1
+	skipSpace := func() {
+		for i >= lower && (content[i] == ' ' || content[i] == '\t' || content[i] == '\r' || content[i] == '\n') {
+			i--
+		}
+	}
+
+	skipSpace()
+	if i >= lower && (content[i] == '"' || content[i] == '\'' || content[i] == '`') {
+		i-- // 匹配位于字符串字面量内，跳过开头的引号
+		skipSpace()
+	}
+	if i < lower {
+		return ""
+	}
+
+	switch content[i] {
+	case ':':
+		// 对象键 (key: value)；三元表达式中的 : 无法区分，同样按键处理
+		i--
+	case '=':
+		// 赋值 (x = value)，排除比较运算符 (==、!=、<=、>=)
+		if i > lower && bytes.IndexByte([]byte("=!<>"), content[i-1]) >= 0 {
+			return ""
+		}
+		i--
+	case '(':
+		// 函数调用的第一个参数 (fn(value))
+		i--
+		skipSpace()
+		if name := identifierBefore(content, lower, i); name != "" {
+			return name + "()"
+		}
+		return ""
+	default:
+		return ""
+	}
+
+	skipSpace()
+	if i < lower {
+		return ""
+	}
+	switch content[i] {
+	case '"', '\'':
+		// 带引号的键名 ("key": value)
+		quote := content[i]
+		end := i
+		for i--; i >= lower && content[i] != quote; i-- {
+			if content[i] == '\n' {
+				return ""
+			}
+		}
+		if i < lower {
+			return ""
+		}
+		return string(content[i+1 : end])
+	case ']':
+		// 下标赋值 (obj["key"] = value)
+		end := i
+		for i--; i >= lower && content[i] != '['; i-- {
+		}
+		if i < lower {
+			return ""
+		}
+		return strings.Trim(strings.TrimSpace(string(content[i+1:end])), `"'`+"`")
+	}
+	return identifierBefore(content, lower, i)
+}
+
+// identifierBefore 返回以 end 结尾的标识符或成员表达式 (如 this.config.apiKey)，end 处不是标识符字符时返回空字符串
+func identifierBefore(content []byte, lower, end int) string {
+	i := end
+	for i >= lower && (isIdentifierByte(content[i]) || content[i] == '.') {
+		i--
+	}
+	return strings.Trim(string(content[i+1:end+1]), ".")
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c == '$' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
@@ -0,0 +1,123 @@
+package scan
+
+import (
+	"jsleaksscan/internal/i18n"
+	"sync"
+	"time"
+)
+
+// urlOutcome 描述单个 URL 请求的结果，供自适应并发控制使用
+type urlOutcome int
+
+const (
+	outcomeOK        urlOutcome = iota // 请求成功 (无论是否发现敏感信息)
+	outcomeFailed                      // 请求失败或响应不可用，不影响并发度
+	outcomeThrottled                   // 被限流 (429/503) 或超时，需要降低并发度
+	outcomeDNSFailed                   // DNS 解析失败 (重试后仍失败)，与请求失败分开统计，不影响并发度
+)
+
+// adaptiveMinLimit 自适应模式下的初始并发度和最小并发度
+const adaptiveMinLimit = 2
+
+// concurrencyLimiter 是容量可动态调整的信号量
+// 固定模式下等价于容量为 -t 的信号量；自适应模式 (-adaptive) 下按 AIMD 策略调整容量：
+// 从低并发开始，每完成一轮 (当前并发度个) 健康请求加 1，遇到限流或超时时减半，上限为 -t。
+// 一次减半之前已经发出的请求随后被限流不再减半，因此同一批并发请求同时被限流时并发度只减半一次
+type concurrencyLimiter struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	limit    int
+	max      int
+	inFlight int
+	adaptive bool
+	verbose  bool
+
+	healthy      int           // 自上次调整后的健康请求数
+	avgLatency   time.Duration // 成功请求延迟的指数移动平均
+	lastDecrease time.Time     // 上一次减半的时间
+}
+
+func newConcurrencyLimiter(max int, adaptive, verbose bool) *concurrencyLimiter {
+	if max < 1 {
+		max = 1
+	}
+	limit := max
+	if adaptive && max > adaptiveMinLimit {
+		limit = adaptiveMinLimit
+	}
+	l := &concurrencyLimiter{limit: limit, max: max, adaptive: adaptive, verbose: verbose}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// acquire 阻塞直到有空闲的并发槽位
+func (l *concurrencyLimiter) acquire() {
+	l.mu.Lock()
+	for l.inFlight >= l.limit {
+		l.cond.Wait()
+	}
+	l.inFlight++
+	l.mu.Unlock()
+}
+
+// release 释放槽位，并在自适应模式下根据请求结果和延迟调整并发度
+func (l *concurrencyLimiter) release(outcome urlOutcome, latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	defer l.cond.Broadcast()
+
+	if !l.adaptive {
+		return
+	}
+	switch outcome {
+	case outcomeThrottled:
+		l.healthy = 0
+		// 请求在上一次减半之前发出，其限流已由那次减半处理
+		if time.Now().Add(-latency).Before(l.lastDecrease) {
+			return
+		}
+		// 乘性减：立即减半
+		l.lastDecrease = time.Now()
+		newLimit := l.limit / 2
+		if newLimit < 1 {
+			newLimit = 1
+		}
+		if newLimit != l.limit {
+			l.limit = newLimit
+			l.logChange("检测到限流或超时")
+		}
+	case outcomeOK:
+		// 延迟明显高于平均水平时视为目标已有压力，不再加大并发
+		slow := l.avgLatency > 0 && latency > 2*l.avgLatency
+		if l.avgLatency == 0 {
+			l.avgLatency = latency
+		} else {
+			l.avgLatency = (l.avgLatency*7 + latency) / 8
+		}
+		if slow {
+			l.healthy = 0
+			return
+		}
+		// 加性增：每完成一轮健康请求加 1
+		l.healthy++
+		if l.healthy >= l.limit && l.limit < l.max {
+			l.limit++
+			l.healthy = 0
+			l.logChange("请求健康")
+		}
+	}
+}
+
+// currentLimit 返回当前并发度
+func (l *concurrencyLimiter) currentLimit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+func (l *concurrencyLimiter) logChange(reason string) {
+	if l.verbose {
+		i18n.Printf("\n自适应并发: %s，并发度调整为 %d\n", reason, l.limit)
+	}
+}
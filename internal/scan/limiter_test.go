@@ -0,0 +1,29 @@
+package scan
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimiterThrottledBurst(t *testing.T) {
+	l := newConcurrencyLimiter(64, true, false)
+	l.limit = 32
+	started := time.Now()
+	for i := 0; i < 32; i++ {
+		l.acquire()
+	}
+	time.Sleep(time.Millisecond)
+	// 同一批并发请求全部被限流，只减半一次
+	for i := 0; i < 32; i++ {
+		l.release(outcomeThrottled, time.Since(started))
+	}
+	if got := l.currentLimit(); got != 16 {
+		t.Fatalf("一批请求被限流后并发度 = %d, 期望 16", got)
+	}
+	// 减半之后发出的请求被限流时再次减半
+	l.acquire()
+	l.release(outcomeThrottled, 0)
+	if got := l.currentLimit(); got != 8 {
+		t.Errorf("减半后发出的请求被限流后并发度 = %d, 期望 8", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"jsleaksscan/internal/config"
+	"jsleaksscan/internal/i18n"
 	"jsleaksscan/internal/rules"
 	"net/http"
 	"os"
@@ -13,15 +14,56 @@ import (
 	"time"
 )
 
-// ScanLocalDirectory 启动本地目录扫描
+// ScanLocalDirectory 启动本地目录扫描，-d 指定的是单个文件时直接扫描该文件
 func ScanLocalDirectory(cfg *config.AppConfig, compiledRules *rules.CompiledRules) error {
 	startTime := time.Now()
-	fmt.Printf("开始本地扫描目录: %s (并发度: %d)\n", cfg.LocalDir, cfg.ThreadNum)
+	if cfg.HARInput != "" {
+		return scanHARInput(cfg, compiledRules, startTime)
+	}
 
 	// 检查目录是否存在
-	if _, err := os.Stat(cfg.LocalDir); os.IsNotExist(err) {
+	rootInfo, err := os.Stat(cfg.LocalDir)
+	if os.IsNotExist(err) {
 		return fmt.Errorf("错误: 目录 '%s' 不存在", cfg.LocalDir)
+	} else if err != nil {
+		return fmt.Errorf("错误: 访问 '%s' 失败: %w", cfg.LocalDir, err)
+	}
+	if cfg.DiffRange != "" {
+		return scanGitDiff(cfg, compiledRules, startTime)
+	}
+	if !rootInfo.IsDir() {
+		return scanLocalFile(cfg, compiledRules, startTime)
+	}
+	i18n.Printf("开始本地扫描目录: %s (并发度: %d)\n", cfg.LocalDir, cfg.ThreadNum)
+
+	// 增量扫描：跳过修改时间早于阈值的文件。-since 优先于状态文件中记录的上次扫描时间
+	modifiedSince := cfg.Since
+	if cfg.StateFile != "" {
+		state, err := loadScanState(cfg.StateFile)
+		if err != nil {
+			return err
+		}
+		if modifiedSince.IsZero() {
+			modifiedSince = state.LastScan
+		}
+	}
+	if !modifiedSince.IsZero() && !cfg.Quiet {
+		i18n.Printf("增量扫描: 只扫描 %s 之后修改过的文件\n", modifiedSince.Format(time.RFC3339))
+	}
+
+	out, err := newResultWriter(cfg, compiledRules)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	// -dedup-content: 记录已扫描文件内容的哈希，跳过内容完全相同的文件
+	var files *contentIndex
+	if cfg.DedupContent {
+		files = newContentIndex(nil)
 	}
+	follower := newImportFollower(cfg, cfg.LocalDir, modifiedSince)
+	scanFile := func(path string) []string { return processLocalFile(path, cfg, compiledRules, files, out) }
 
 	// 使用信号量控制并发处理文件的数量
 	workerSemaphore := make(chan struct{}, cfg.ThreadNum)
@@ -36,35 +78,60 @@ func ScanLocalDirectory(cfg *config.AppConfig, compiledRules *rules.CompiledRule
 		go func(workerID int) {
 			defer wg.Done()
 			if !cfg.Quiet && cfg.Verbose {
-				fmt.Printf("[Worker %d] 启动\n", workerID)
+				i18n.Printf("[Worker %d] 启动\n", workerID)
 			}
 			for filePath := range fileQueue {
 				workerSemaphore <- struct{}{} // 获取一个信号量槽位
 				if !cfg.Quiet && cfg.Verbose {
-					fmt.Printf("[Worker %d] 开始处理: %s\n", workerID, filePath)
+					i18n.Printf("[Worker %d] 开始处理: %s\n", workerID, filePath)
+				}
+				if follower != nil {
+					follower.process(filePath, scanFile)
+				} else {
+					scanFile(filePath)
 				}
-				processLocalFile(filePath, cfg, compiledRules)
 				if !cfg.Quiet && cfg.Verbose {
-					fmt.Printf("[Worker %d] 完成处理: %s\n", workerID, filePath)
+					i18n.Printf("[Worker %d] 完成处理: %s\n", workerID, filePath)
 				}
 				<-workerSemaphore // 释放信号量槽位
 			}
 			if !cfg.Quiet && cfg.Verbose {
-				fmt.Printf("[Worker %d] 退出\n", workerID)
+				i18n.Printf("[Worker %d] 退出\n", workerID)
 			}
 		}(i)
 	}
 
+	// 合并默认和用户追加的扩展名及文本 MIME 类型，-strict-types 时只使用 -ext 指定的扩展名且不做 MIME 回退检测
+	extensions := scanExtensionSet(cfg)
+	mimeTypes := textMimeTypes(cfg.ExtraMimeTypes)
+	if cfg.StrictTypes {
+		mimeTypes = nil
+		if !cfg.Quiet {
+			i18n.Printf("严格文件类型模式 (-strict-types): 只扫描扩展名为 %s 的文件，不做 MIME 检测。\n", strings.Join(cfg.ExtraExtensions, ", "))
+		}
+	}
+
 	// --- 遍历目录并将符合条件的文件放入队列 ---
 	// 使用 WaitGroup 确保 Walk 完成后再关闭 fileQueue
 	var walkWg sync.WaitGroup
 	walkWg.Add(1)
 	go func() {
 		defer walkWg.Done()
-		err := filepath.Walk(cfg.LocalDir, func(path string, info os.FileInfo, err error) error {
+		if cfg.NetworkFS {
+			if err := walkNetworkFS(cfg, modifiedSince, out.limit, fileQueue); err != nil {
+				i18n.Printf("错误: 遍历目录 '%s' 时发生错误: %v\n", cfg.LocalDir, err)
+			}
+			return
+		}
+		// visit 处理遍历到的每一项，将符合条件的文件放入队列
+		visit := func(path string, info os.FileInfo, err error) error {
+			// 结果文件总大小达到 -max-output-size 上限后不再遍历
+			if out.limit.exceeded() {
+				return filepath.SkipAll
+			}
 			if err != nil {
 				// 打印访问错误并继续遍历其他文件
-				fmt.Printf("警告: 访问路径 '%s' 出错: %v\n", path, err)
+				i18n.Printf("警告: 访问路径 '%s' 出错: %v\n", path, err)
 				return nil // 继续遍历
 			}
 
@@ -73,16 +140,30 @@ func ScanLocalDirectory(cfg *config.AppConfig, compiledRules *rules.CompiledRule
 				return nil
 			}
 
+			// 跳过自上次扫描后未修改的文件
+			if !modifiedSince.IsZero() && info.ModTime().Before(modifiedSince) {
+				if !cfg.Quiet && cfg.Verbose {
+					i18n.Printf("跳过文件 (自上次扫描后未修改): %s\n", path)
+				}
+				return nil
+			}
+
 			// 检查文件是否符合扫描条件
-			if shouldScanFile(path, info) {
+			if shouldScanFile(path, info, extensions, mimeTypes) || (cfg.SniffGzip && isCompressedTextFile(path, extensions)) || (cfg.ScanDocs && isDocumentFile(path)) || (cfg.ScanExtensions && isExtensionPackage(path)) {
 				fileQueue <- path // 将文件路径发送到队列
 			} else if !cfg.Quiet && cfg.Verbose {
-				fmt.Printf("跳过文件 (不符合条件): %s\n", path)
+				i18n.Printf("跳过文件 (不符合条件): %s\n", path)
 			}
 			return nil
-		})
+		}
+		var err error
+		if cfg.FollowSymlinks {
+			err = walkFollowingSymlinks(cfg, visit)
+		} else {
+			err = filepath.Walk(cfg.LocalDir, visit)
+		}
 		if err != nil {
-			fmt.Printf("错误: 遍历目录 '%s' 时发生错误: %v\n", cfg.LocalDir, err)
+			i18n.Printf("错误: 遍历目录 '%s' 时发生错误: %v\n", cfg.LocalDir, err)
 			// 即使遍历出错，也尝试关闭队列，让 worker 退出
 		}
 	}()
@@ -92,83 +173,267 @@ func ScanLocalDirectory(cfg *config.AppConfig, compiledRules *rules.CompiledRule
 		walkWg.Wait()
 		close(fileQueue)
 		if !cfg.Quiet && cfg.Verbose {
-			fmt.Println("文件遍历完成，已关闭文件队列。")
+			i18n.Println("文件遍历完成，已关闭文件队列。")
 		}
 	}()
 
 	// 等待所有 worker 完成处理
 	wg.Wait()
 
-	fmt.Printf("本地扫描完成。总耗时: %v\n", time.Since(startTime))
+	reportFollowedImports(cfg, follower)
+	if files != nil && files.duplicateCount() > 0 {
+		path := filepath.Join(cfg.OutputDir, duplicateFilesFile)
+		if _, err := files.writeDuplicateGroups(path); err != nil {
+			i18n.Printf("错误: %v\n", err)
+		} else if !cfg.Quiet {
+			i18n.Printf("跳过 %d 个与已扫描文件内容相同的文件 (-dedup-content)，对应关系已写入: %s\n", files.duplicateCount(), path)
+		}
+	}
+
+	// 有发现无法写入时不更新增量扫描状态，以便下次扫描重新处理这些文件
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	// 记录本次扫描的开始时间，扫描期间被修改的文件在下次扫描时仍会被覆盖
+	if cfg.StateFile != "" {
+		if err := saveScanState(cfg.StateFile, scanState{LastScan: startTime}); err != nil {
+			i18n.Printf("错误: %v\n", err)
+		} else if !cfg.Quiet && cfg.Verbose {
+			i18n.Printf("已更新增量扫描状态文件: %s\n", cfg.StateFile)
+		}
+	}
+
+	i18n.Printf("本地扫描完成。总耗时: %v\n", time.Since(startTime))
 	return nil
 }
 
-// processLocalFile 读取并处理单个本地文件
-func processLocalFile(filePath string, cfg *config.AppConfig, compiledRules *rules.CompiledRules) {
+// scanLocalFile 直接扫描 -d 指定的单个文件，不经过目录遍历
+// 用户明确指定了文件，因此不检查扩展名、MIME 类型和增量扫描条件
+func scanLocalFile(cfg *config.AppConfig, compiledRules *rules.CompiledRules, startTime time.Time) error {
+	i18n.Printf("开始本地扫描文件: %s\n", cfg.LocalDir)
+
+	out, err := newResultWriter(cfg, compiledRules)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	// 跟随导入时只跟随该文件所在目录内的文件
+	if follower := newImportFollower(cfg, filepath.Dir(cfg.LocalDir), time.Time{}); follower != nil {
+		follower.process(cfg.LocalDir, func(path string) []string { return processLocalFile(path, cfg, compiledRules, nil, out) })
+		reportFollowedImports(cfg, follower)
+	} else {
+		processLocalFile(cfg.LocalDir, cfg, compiledRules, nil, out)
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	i18n.Printf("本地扫描完成。总耗时: %v\n", time.Since(startTime))
+	return nil
+}
+
+// maxDecompressedFileSize 本地 gzip 文件解压后的最大处理大小
+const maxDecompressedFileSize = 50 * 1024 * 1024 // 50MB
+
+// reportFollowedImports 报告通过导入额外扫描的文件数 (-follow-imports)
+func reportFollowedImports(cfg *config.AppConfig, follower *importFollower) {
+	if follower != nil && !cfg.Quiet {
+		i18n.Printf("跟随导入 (-follow-imports) 额外扫描了 %d 个文件。\n", follower.followedCount())
+	}
+}
+
+// processLocalFile 读取并处理单个本地文件，启用 -follow-imports 时返回其中的相对导入路径
+// files 不为 nil 时 (-dedup-content) 跳过内容与之前扫描过的文件完全相同的文件
+func processLocalFile(filePath string, cfg *config.AppConfig, compiledRules *rules.CompiledRules, files *contentIndex, out *resultWriter) []string {
+	// 文档 (-scan-docs) 扫描提取出的文本，来源标识为 "<路径>#text"
+	if cfg.ScanDocs && isDocumentFile(filePath) {
+		text, truncated, err := extractDocumentText(filePath)
+		if err != nil {
+			i18n.Printf("警告: 提取文档 '%s' 的文本失败: %v\n", filePath, err)
+			return nil
+		}
+		if truncated {
+			i18n.Printf("警告: 文档 '%s' 的文本超过 %dMB 限制，只处理了部分内容。\n", filePath, maxDocumentTextSize/(1024*1024))
+		}
+		processLocalContent(filePath+documentSourceSuffix, text, cfg, compiledRules, out)
+		return nil
+	}
+
+	// 浏览器扩展包 (-scan-extensions) 中的每个文件单独扫描，来源标识为 "<路径>!<包内路径>"
+	if cfg.ScanExtensions && isExtensionPackage(filePath) {
+		err := scanExtensionPackage(filePath, func(source string, content []byte) {
+			processLocalContent(source, content, cfg, compiledRules, out)
+		})
+		if err != nil {
+			i18n.Printf("警告: 扫描扩展包 '%s' 失败: %v\n", filePath, err)
+		}
+		return nil
+	}
+
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		fmt.Printf("错误: 读取文件 '%s' 失败: %v\n", filePath, err)
-		return
+		i18n.Printf("错误: 读取文件 '%s' 失败: %v\n", filePath, err)
+		return nil
 	}
 
+	// 内容相同的文件只扫描第一个，被跳过的文件仍然跟随其中的导入 (相对路径可能指向不同的文件)
+	duplicate := false
+	if files != nil {
+		var firstSource string
+		if firstSource, duplicate = files.markSeen(content, filePath); duplicate && !cfg.Quiet && cfg.Verbose {
+			i18n.Printf("文件 '%s' 与 '%s' 内容相同，跳过扫描。\n", filePath, firstSource)
+		}
+	}
+	if !duplicate {
+		processLocalContent(filePath, content, cfg, compiledRules, out)
+	}
+	if cfg.FollowImports {
+		return importSpecifiers(filePath, content)
+	}
+	return nil
+}
+
+// processLocalContent 匹配一个本地来源的内容并输出结果
+func processLocalContent(filePath string, content []byte, cfg *config.AppConfig, compiledRules *rules.CompiledRules, out *resultWriter) {
+	started := time.Now()
+	if out.skipSource(filePath) {
+		return
+	}
 	// 如果文件为空，则跳过处理
 	if len(content) == 0 {
 		if !cfg.Quiet && cfg.Verbose {
-			fmt.Printf("跳过空文件: %s\n", filePath)
+			i18n.Printf("跳过空文件: %s\n", filePath)
 		}
 		return
 	}
 
+	// 识别 gzip 压缩的文件 (例如 .js.gz) 并解压后扫描
+	if cfg.SniffGzip {
+		decompressed, ok, truncated, err := maybeGunzip(content, maxDecompressedFileSize)
+		if err != nil {
+			i18n.Printf("警告: 文件 '%s' 看似 gzip 数据但%v，按原始内容扫描。\n", filePath, err)
+		} else if ok {
+			content = decompressed
+			if truncated {
+				i18n.Printf("警告: 文件 '%s' 解压后超过 %dMB 限制，只处理了部分内容。\n", filePath, maxDecompressedFileSize/(1024*1024))
+			}
+		}
+	}
+
 	// 使用通用内容处理函数
 	// 本地扫描通常文件较大，可以考虑默认开启并发正则匹配
-	results := processContent(filePath, content, compiledRules, true)
+	sink, finish := out.sourceWriter(nil)
+	results := processContent(filePath, content, compiledRules, cfg, true, sink)
+	out.recordSource(filePath, len(content), len(results), started)
 
 	if len(results) > 0 {
-		outputFilePath := GetOutputFilePath(cfg.OutputDir, filePath)
-		if err := WriteResultsToFile(outputFilePath, results); err != nil {
-			fmt.Printf("错误: 写入结果到 '%s' 失败: %v\n", outputFilePath, err)
+		outputFilePaths, err := finish(results)
+		if err != nil {
+			i18n.Printf("错误: %v\n", err)
 		} else {
-			if !cfg.Quiet { // 在非静默模式下报告写入成功
-				fmt.Printf("发现敏感信息 [%s] -> %s\n", filePath, outputFilePath)
+			if !cfg.Quiet && len(outputFilePaths) > 0 { // 在非静默模式下报告写入成功 (达到 -max-output-size 上限后没有写入的文件)
+				i18n.Printf("发现敏感信息 [%s] -> %s\n", filePath, strings.Join(outputFilePaths, ", "))
 			}
 		}
 	} else if !cfg.Quiet && cfg.Verbose {
-		fmt.Printf("文件 '%s' 未发现匹配项。\n", filePath)
+		i18n.Printf("文件 '%s' 未发现匹配项。\n", filePath)
 	}
 }
 
+// jsExtensions 按扩展名直接扫描的常见脚本和文本文件类型
+var jsExtensions = map[string]bool{
+	".js":   true,
+	".jsx":  true,
+	".ts":   true,
+	".tsx":  true,
+	".html": true,
+	".htm":  true,
+	".json": true,
+	".yaml": true,
+	".yml":  true,
+	".xml":  true,
+	".txt":  true,
+	".log":  true,
+	".conf": true,
+	".cfg":  true,
+	".ini":  true,
+	".md":   true,
+	".py":   true, // 添加其他可能包含敏感信息的脚本或配置文件类型
+	".sh":   true,
+	".rb":   true,
+	".php":  true,
+	".go":   true, // 扫描 Go 源码本身
+	".java": true,
+	".cs":   true,
+}
+
+// defaultTextMimeTypes 默认视为可扫描文本的 MIME 类型 (用于无扩展名或未知扩展名文件的回退检测)
+var defaultTextMimeTypes = map[string]bool{
+	"text/plain":                true,
+	"text/html":                 true,
+	"text/javascript":           true, // 现代标准的 JS 类型
+	"text/xml":                  true,
+	"text/css":                  true,
+	"application/javascript":    true,
+	"application/ecmascript":    true,
+	"application/json":          true,
+	"application/manifest+json": true,
+	"application/xml":           true,
+	"application/x-yaml":        true, // YAML
+	// application/wasm, application/octet-stream 等二进制类型不在此列
+}
+
+// scanExtensionSet 返回按扩展名扫描的文件类型: 默认类型与用户追加类型 (-ext) 的合集，-strict-types 时只有 -ext 指定的类型
+func scanExtensionSet(cfg *config.AppConfig) map[string]bool {
+	extensions := make(map[string]bool, len(jsExtensions)+len(cfg.ExtraExtensions))
+	if !cfg.StrictTypes {
+		for ext, ok := range jsExtensions {
+			extensions[ext] = ok
+		}
+	}
+	for _, ext := range cfg.ExtraExtensions {
+		extensions[ext] = true
+	}
+	return extensions
+}
+
+// textMimeTypes 返回默认文本 MIME 类型与用户追加类型 (-mime-types) 的合集
+func textMimeTypes(extra []string) map[string]bool {
+	types := make(map[string]bool, len(defaultTextMimeTypes)+len(extra))
+	for mimeType, ok := range defaultTextMimeTypes {
+		types[mimeType] = ok
+	}
+	for _, mimeType := range extra {
+		mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+		if mimeType != "" {
+			types[mimeType] = true
+		}
+	}
+	return types
+}
+
+// isCompressedTextFile 判断文件是否为 gzip 压缩的可扫描文本文件 (例如 .js.gz)，extensions 为可扫描的扩展名
+func isCompressedTextFile(path string, extensions map[string]bool) bool {
+	lower := strings.ToLower(path)
+	if !strings.HasSuffix(lower, ".gz") {
+		return false
+	}
+	return extensions[filepath.Ext(strings.TrimSuffix(lower, ".gz"))]
+}
+
 // shouldScanFile 判断一个本地文件是否应该被扫描
-func shouldScanFile(path string, info os.FileInfo) bool {
+// extensions 为按扩展名扫描的类型，mimeTypes 为 MIME 回退检测时视为文本的类型集合，为 nil 时不做 MIME 检测 (-strict-types)
+func shouldScanFile(path string, info os.FileInfo, extensions, mimeTypes map[string]bool) bool {
 	// 1. 基于文件扩展名 (常见脚本和文本文件)
-	jsExtensions := map[string]bool{
-		".js":   true,
-		".jsx":  true,
-		".ts":   true,
-		".tsx":  true,
-		".html": true,
-		".htm":  true,
-		".json": true,
-		".yaml": true,
-		".yml":  true,
-		".xml":  true,
-		".txt":  true,
-		".log":  true,
-		".conf": true,
-		".cfg":  true,
-		".ini":  true,
-		".md":   true,
-		".py":   true, // 添加其他可能包含敏感信息的脚本或配置文件类型
-		".sh":   true,
-		".rb":   true,
-		".php":  true,
-		".go":   true, // 扫描 Go 源码本身
-		".java": true,
-		".cs":   true,
-	}
 	ext := strings.ToLower(filepath.Ext(path))
-	if jsExtensions[ext] {
+	if extensions[ext] {
 		return true
 	}
+	if mimeTypes == nil {
+		return false
+	}
 
 	// 2. 基于文件大小 (避免扫描过大的二进制文件)
 	// 可根据需要调整大小限制
@@ -179,7 +444,7 @@ func shouldScanFile(path string, info os.FileInfo) bool {
 	}
 	// 对于没有明确扩展名或未知扩展名的文件，可以尝试读取文件头判断 MIME 类型
 	// 只有当文件较小且扩展名不明确时才进行 MIME 检测，以提高效率
-	if ext == "" || !jsExtensions[ext] && info.Size() < 1*1024*1024 { // 小于 1MB 才检测 MIME
+	if ext == "" || !extensions[ext] && info.Size() < 1*1024*1024 { // 小于 1MB 才检测 MIME
 		file, err := os.Open(path)
 		if err != nil {
 			// fmt.Printf("Warning: Could not open file %s for MIME type detection: %v\n", path, err)
@@ -198,24 +463,13 @@ func shouldScanFile(path string, info os.FileInfo) bool {
 		if n > 0 {
 			// 检测 Content-Type
 			mimeType := http.DetectContentType(buffer[:n])
-			// 常见的文本相关 MIME 类型
-			textMimeTypes := map[string]bool{
-				"text/plain":               true,
-				"text/html":                true,
-				"application/javascript":   true,
-				"application/json":         true,
-				"application/xml":          true,
-				"application/x-yaml":       true,  // YAML
-				"application/octet-stream": false, // 通常是二进制，但有时也可能是未知文本
-				// 可以根据需要添加更多 MIME 类型
-			}
 			// 去掉 charset 等参数部分
-			mimeBase := strings.Split(mimeType, ";")[0]
-			if textMimeTypes[mimeBase] {
+			mimeBase := strings.TrimSpace(strings.Split(mimeType, ";")[0])
+			if mimeTypes[mimeBase] {
 				return true
 			}
 			// 特殊处理：如果 MIME 是 octet-stream 但扩展名是已知的文本类型，也扫描
-			if mimeBase == "application/octet-stream" && jsExtensions[ext] {
+			if mimeBase == "application/octet-stream" && extensions[ext] {
 				return true
 			}
 		}
@@ -0,0 +1,85 @@
+package scan
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// manifestEntry 是覆盖清单中的一个来源
+type manifestEntry struct {
+	Source      string  `json:"source"`
+	Size        int     `json:"size"` // 扫描的内容大小 (字节，解压后)
+	HasFindings bool    `json:"has_findings"`
+	Findings    int     `json:"findings"`
+	DurationMS  float64 `json:"duration_ms"` // 处理该来源的耗时 (毫秒)，URL 包括请求和读取响应的时间
+}
+
+// manifestFile 是 -manifest 写入的 JSON 文件
+type manifestFile struct {
+	RunID         string          `json:"run_id"`
+	Mode          string          `json:"mode"`
+	StartedAt     time.Time       `json:"started_at"`
+	FinishedAt    time.Time       `json:"finished_at"`
+	TotalSources  int             `json:"total_sources"`
+	TotalFindings int             `json:"total_findings"`
+	Sources       []manifestEntry `json:"sources"`
+}
+
+// manifestRecorder 收集扫描过的每个来源，扫描结束时写入覆盖清单 (-manifest)。可被多个 goroutine 并发使用
+type manifestRecorder struct {
+	mu      sync.Mutex
+	path    string
+	started time.Time
+	entries []manifestEntry
+}
+
+func newManifestRecorder(path string) *manifestRecorder {
+	return &manifestRecorder{path: path, started: time.Now().UTC()}
+}
+
+// record 记录一个扫描完成的来源
+func (m *manifestRecorder) record(source string, size, findings int, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, manifestEntry{
+		Source:      source,
+		Size:        size,
+		HasFindings: findings > 0,
+		Findings:    findings,
+		DurationMS:  float64(elapsed.Microseconds()) / 1000,
+	})
+}
+
+// write 按来源排序后将清单写入文件，使同一输入多次运行的清单便于比较
+func (m *manifestRecorder) write(runID, mode string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	slices.SortFunc(m.entries, func(a, b manifestEntry) int { return cmp.Compare(a.Source, b.Source) })
+	manifest := manifestFile{
+		RunID:        runID,
+		Mode:         mode,
+		StartedAt:    m.started,
+		FinishedAt:   time.Now().UTC(),
+		TotalSources: len(m.entries),
+		Sources:      m.entries,
+	}
+	if manifest.Sources == nil {
+		manifest.Sources = []manifestEntry{}
+	}
+	for _, entry := range m.entries {
+		manifest.TotalFindings += entry.Findings
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("序列化覆盖清单失败: %w", err)
+	}
+	if err := os.WriteFile(m.path, data, 0644); err != nil {
+		return 0, fmt.Errorf("写入覆盖清单 '%s' 失败: %w", m.path, err)
+	}
+	return len(m.entries), nil
+}
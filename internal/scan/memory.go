@@ -0,0 +1,51 @@
+package scan
+
+import (
+	"context"
+	"jsleaksscan/internal/i18n"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// memoryBudget 限制所有 worker 同时持有的响应体总字节数 (-max-memory)
+// 读取响应体前按 Content-Length (未知时按 -max-body-size) 预留额度，额度不足时阻塞等待，
+// 使峰值内存与并发度和响应体大小无关；nil 表示不限制
+type memoryBudget struct {
+	sem     *semaphore.Weighted
+	limit   int64
+	verbose bool
+}
+
+func newMemoryBudget(limit int64, verbose bool) *memoryBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &memoryBudget{sem: semaphore.NewWeighted(limit), limit: limit, verbose: verbose}
+}
+
+// acquire 为读取 URL 的响应体预留 n 字节，返回实际预留的字节数，调用方需用 release 归还
+// 超过预算总量的响应体按总量预留 (即独占全部预算)，避免永远无法获得额度
+func (m *memoryBudget) acquire(ctx context.Context, n int64, url string) (int64, error) {
+	if m == nil {
+		return 0, nil
+	}
+	n = min(max(n, 1), m.limit)
+	if m.sem.TryAcquire(n) {
+		return n, nil
+	}
+	if m.verbose {
+		i18n.Printf("内存预算不足，等待其他响应处理完成后再读取 URL '%s' 的响应体 (%d 字节)。\n", url, n)
+	}
+	if err := m.sem.Acquire(ctx, n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// release 归还 n 字节的额度
+func (m *memoryBudget) release(n int64) {
+	if m == nil || n <= 0 {
+		return
+	}
+	m.sem.Release(n)
+}
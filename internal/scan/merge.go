@@ -0,0 +1,65 @@
+package scan
+
+import (
+	"slices"
+	"strings"
+)
+
+// mergeAdjacentLines 将同一规则在连续行上的匹配合并为一条跨越行范围的发现 (-merge-lines)，
+// 用于按行报告的多行密钥 (如逐行匹配的 PEM 私钥块)。合并后的匹配内容为各匹配按偏移顺序以换行符拼接，
+// 同一行上的多个匹配 (如压缩成一行的 JS 中的匹配) 不合并。Line 为第一处匹配的行号，EndLine 为最后一处匹配结束的行号；结果需已设置 Line
+func mergeAdjacentLines(results []ScanResult) []ScanResult {
+	if len(results) < 2 {
+		return results
+	}
+
+	// 按规则分组，保持规则首次出现的顺序
+	var order []string
+	groups := make(map[string][]ScanResult)
+	for _, result := range results {
+		if _, ok := groups[result.Rule]; !ok {
+			order = append(order, result.Rule)
+		}
+		groups[result.Rule] = append(groups[result.Rule], result)
+	}
+
+	merged := make([]ScanResult, 0, len(results))
+	for _, rule := range order {
+		group := groups[rule]
+		slices.SortStableFunc(group, func(a, b ScanResult) int { return a.Offset - b.Offset })
+
+		current := group[0]
+		currentEnd := matchEndLine(current)
+		var parts []string
+		for _, next := range group[1:] {
+			if next.Line == currentEnd+1 {
+				if parts == nil {
+					parts = []string{current.Match}
+				}
+				parts = append(parts, next.Match)
+				currentEnd = max(currentEnd, matchEndLine(next))
+				continue
+			}
+			merged = append(merged, finishMerge(current, parts, currentEnd))
+			current, currentEnd, parts = next, matchEndLine(next), nil
+		}
+		merged = append(merged, finishMerge(current, parts, currentEnd))
+	}
+	return merged
+}
+
+// matchEndLine 返回匹配内容结束所在的行号 (匹配本身可能跨行)
+func matchEndLine(result ScanResult) int {
+	return result.Line + strings.Count(result.Match, "\n")
+}
+
+// finishMerge 用合并的各部分生成最终的发现，没有合并其他匹配时原样返回
+func finishMerge(first ScanResult, parts []string, endLine int) ScanResult {
+	if parts != nil {
+		first.Match = strings.Join(parts, "\n")
+	}
+	if endLine > first.Line {
+		first.EndLine = endLine
+	}
+	return first
+}
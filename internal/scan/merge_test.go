@@ -0,0 +1,29 @@
+package scan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeAdjacentLines(t *testing.T) {
+	results := []ScanResult{
+		{Rule: "pem", Match: "-----BEGIN", Line: 3, Offset: 30},
+		{Rule: "pem", Match: "MIIE", Line: 4, Offset: 41},
+		{Rule: "pem", Match: "-----END", Line: 5, Offset: 46},
+		// 压缩成一行的 JS 中同一规则的多个匹配
+		{Rule: "key", Match: "key_a", Line: 1, Offset: 0},
+		{Rule: "key", Match: "key_b", Line: 1, Offset: 10},
+		{Rule: "key", Match: "key_c", Line: 1, Offset: 20},
+		{Rule: "pem", Match: "other", Line: 9, Offset: 90},
+	}
+	want := []ScanResult{
+		{Rule: "pem", Match: "-----BEGIN\nMIIE\n-----END", Line: 3, EndLine: 5, Offset: 30},
+		{Rule: "pem", Match: "other", Line: 9, Offset: 90},
+		{Rule: "key", Match: "key_a", Line: 1, Offset: 0},
+		{Rule: "key", Match: "key_b", Line: 1, Offset: 10},
+		{Rule: "key", Match: "key_c", Line: 1, Offset: 20},
+	}
+	if got := mergeAdjacentLines(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeAdjacentLines = %+v\n期望 %+v", got, want)
+	}
+}
@@ -0,0 +1,208 @@
+package scan
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"jsleaksscan/internal/i18n"
+	"jsleaksscan/internal/rules"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// ndjsonRecord 是 NDJSON 输出中的一行，包含从规则元信息中解析出的字段
+type ndjsonRecord struct {
+	Timestamp   time.Time  `json:"timestamp"` // 发现时间 (UTC，而非扫描开始时间)
+	RunID       string     `json:"run_id"`    // 扫描运行 ID，同一次运行的所有发现相同
+	Source      string     `json:"source"`
+	Rule        string     `json:"rule"`
+	Pattern     string     `json:"pattern,omitempty"` // 产生该匹配的正则表达式或字面量
+	Severity    string     `json:"severity,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Tags        []string   `json:"tags,omitempty"` // 规则的分类标签
+	Match       string     `json:"match"`
+	Encoding    string     `json:"match_encoding,omitempty"` // match 的编码 (hex/base64)，普通文本时省略
+	Line        int        `json:"line,omitempty"`
+	EndLine     int        `json:"end_line,omitempty"`  // -merge-lines 合并多行匹配后的结束行号
+	Status      int        `json:"status,omitempty"`    // 仅 URL 扫描
+	FinalURL    string     `json:"final_url,omitempty"` // 仅 URL 扫描
+	Fingerprint string     `json:"fingerprint"`
+	JWT         *JWTClaims `json:"jwt,omitempty"` // -jwt 解码出的 JWT 信息
+	Confidence  int        `json:"confidence"`    // 综合置信度 (0-100)
+	// Metadata 是从云服务商密钥中解析出的元信息 (账号、项目等)
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Tier     string            `json:"tier,omitempty"` // 分层扫描 (-deep) 时产生该发现的层
+	Key      string            `json:"key,omitempty"`  // -key-context 时匹配所属的变量、对象键或函数名
+}
+
+// ndjsonBufferSize 是 NDJSON 写缓冲区的默认大小
+const ndjsonBufferSize = 64 * 1024
+
+// flushPolicy 控制缓冲数据何时写入文件
+// 两项均为零时每个来源写完立即刷新；否则按时间间隔和/或缓冲字节数批量刷新
+type flushPolicy struct {
+	interval time.Duration // 定时刷新间隔 (-flush-interval)
+	bytes    int           // 缓冲数据达到该字节数时刷新 (-flush-bytes)
+}
+
+func (p flushPolicy) batched() bool {
+	return p.interval > 0 || p.bytes > 0
+}
+
+// ndjsonWriter 将所有来源的发现以 NDJSON 格式 (每行一个 JSON 对象) 追加写入同一个文件，
+// 便于导入 Elasticsearch/Splunk 等系统；也可以写入 Unix 套接字或命名管道 (-socket)。
+// 可被多个 goroutine 并发使用
+type ndjsonWriter struct {
+	mu     sync.Mutex
+	path   string
+	file   io.WriteCloser
+	writer *bufio.Writer
+	meta   map[string]rules.RuleMeta
+	flush  flushPolicy
+	stop   chan struct{} // 关闭时通知定时刷新 goroutine 退出
+	done   chan struct{}
+}
+
+func newNDJSONWriter(path string, compiledRules *rules.CompiledRules, flush flushPolicy) (*ndjsonWriter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开 NDJSON 输出文件 '%s' 失败: %w", path, err)
+	}
+	return newNDJSONStream(path, file, compiledRules, flush), nil
+}
+
+// newNDJSONSocketWriter 连接 Unix 域套接字或打开命名管道 (FIFO)，将发现以 NDJSON 格式实时写入
+// 套接字或管道的读取端由调用方 (例如编排程序) 负责创建和监听；打开命名管道会阻塞到读取端打开为止
+func newNDJSONSocketWriter(path string, compiledRules *rules.CompiledRules) (*ndjsonWriter, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开结果套接字 '%s' 失败: %w", path, err)
+	}
+	var conn io.WriteCloser
+	switch {
+	case info.Mode()&os.ModeSocket != 0:
+		conn, err = net.Dial("unix", path)
+	case info.Mode()&os.ModeNamedPipe != 0:
+		conn, err = os.OpenFile(path, os.O_WRONLY, 0)
+	default:
+		return nil, fmt.Errorf("结果套接字 '%s' 不是 Unix 域套接字或命名管道", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("连接结果套接字 '%s' 失败: %w", path, err)
+	}
+	// 每个来源写完立即发送，便于读取端实时处理
+	return newNDJSONStream(path, conn, compiledRules, flushPolicy{}), nil
+}
+
+// newNDJSONStream 在已打开的输出上创建 NDJSON 写入器，path 仅用于错误信息
+func newNDJSONStream(path string, file io.WriteCloser, compiledRules *rules.CompiledRules, flush flushPolicy) *ndjsonWriter {
+	// 缓冲区至少能容纳 -flush-bytes 字节，避免 bufio 在达到阈值前自行写出
+	bufferSize := max(ndjsonBufferSize, flush.bytes)
+	w := &ndjsonWriter{
+		path:   path,
+		file:   file,
+		writer: bufio.NewWriterSize(file, bufferSize),
+		meta:   compiledRules.Meta,
+		flush:  flush,
+	}
+	if flush.interval > 0 {
+		w.stop = make(chan struct{})
+		w.done = make(chan struct{})
+		go w.flushPeriodically()
+	}
+	return w
+}
+
+// flushPeriodically 按 -flush-interval 定时将缓冲数据写入文件，直到 Close 被调用
+func (w *ndjsonWriter) flushPeriodically() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.flush.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.mu.Lock()
+			if err := w.writer.Flush(); err != nil {
+				i18n.Printf("警告: 刷新缓冲区到 '%s' 失败: %v\n", w.path, err)
+			}
+			w.mu.Unlock()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// write 写入一个来源的全部结果，返回写入的字节数
+// 未设置刷新策略时立即刷新到文件，否则仅在缓冲数据达到 -flush-bytes 时刷新
+func (w *ndjsonWriter) write(results []ScanResult) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	counted := &countingWriter{w: w.writer}
+	encoder := json.NewEncoder(counted)
+	encoder.SetEscapeHTML(false)
+	for _, result := range results {
+		record := ndjsonRecord{
+			Timestamp:   result.FoundAt,
+			RunID:       result.RunID,
+			Source:      result.Source,
+			Rule:        result.Rule,
+			Pattern:     result.Pattern,
+			Severity:    result.Severity,
+			Description: w.meta[result.Rule].Description,
+			Tags:        w.meta[result.Rule].Tags,
+			Match:       result.Match,
+			Encoding:    result.Encoding,
+			Line:        result.Line,
+			EndLine:     result.EndLine,
+			Status:      result.Status,
+			FinalURL:    result.FinalURL,
+			Fingerprint: result.Fingerprint,
+			JWT:         result.JWT,
+			Confidence:  result.Confidence,
+			Metadata:    result.Metadata,
+			Tier:        result.Tier,
+			Key:         result.Key,
+		}
+		if err := encoder.Encode(record); err != nil {
+			return counted.n, fmt.Errorf("写入 NDJSON 结果到 '%s' 失败: %w", w.path, err)
+		}
+	}
+	if w.flush.batched() && (w.flush.bytes == 0 || w.writer.Buffered() < w.flush.bytes) {
+		return counted.n, nil
+	}
+	if err := w.writer.Flush(); err != nil {
+		return counted.n, fmt.Errorf("刷新缓冲区到 '%s' 失败: %w", w.path, err)
+	}
+	return counted.n, nil
+}
+
+// countingWriter 统计写入的字节数
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
+
+// Close 刷新剩余数据并关闭文件
+func (w *ndjsonWriter) Close() error {
+	if w.stop != nil {
+		close(w.stop)
+		<-w.done
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.writer.Flush(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("刷新缓冲区到 '%s' 失败: %w", w.path, err)
+	}
+	return w.file.Close()
+}
@@ -0,0 +1,62 @@
+package scan
+
+import (
+	"io/fs"
+	"jsleaksscan/internal/config"
+	"jsleaksscan/internal/i18n"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// walkNetworkFS 遍历 SMB/NFS 等网络文件系统上的目录 (-network-fs)，将候选文件放入 queue。
+// 与默认遍历相比减少每个文件的元数据请求: 使用 filepath.WalkDir 按批读取目录项且不对每一项单独 stat，
+// 只按扩展名筛选文件 (不打开文件读取文件头做 MIME 检测)，只有需要按修改时间过滤时才对候选文件 stat。
+// 结果文件总大小达到 -max-output-size 上限 (limit) 后停止遍历
+func walkNetworkFS(cfg *config.AppConfig, modifiedSince time.Time, limit *outputLimit, queue chan<- string) error {
+	extensions := scanExtensionSet(cfg)
+	return filepath.WalkDir(cfg.LocalDir, func(path string, d fs.DirEntry, err error) error {
+		if limit.exceeded() {
+			return filepath.SkipAll
+		}
+		if err != nil {
+			i18n.Printf("警告: 访问路径 '%s' 出错: %v\n", path, err)
+			return nil // 继续遍历
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		if !isCandidateByName(path, cfg, extensions) {
+			if !cfg.Quiet && cfg.Verbose {
+				i18n.Printf("跳过文件 (不符合条件): %s\n", path)
+			}
+			return nil
+		}
+
+		if !modifiedSince.IsZero() {
+			info, err := d.Info()
+			if err != nil {
+				i18n.Printf("警告: 访问路径 '%s' 出错: %v\n", path, err)
+				return nil
+			}
+			if info.ModTime().Before(modifiedSince) {
+				if !cfg.Quiet && cfg.Verbose {
+					i18n.Printf("跳过文件 (自上次扫描后未修改): %s\n", path)
+				}
+				return nil
+			}
+		}
+
+		queue <- path
+		return nil
+	})
+}
+
+// isCandidateByName 只按文件名判断文件是否需要扫描: extensions 中的扩展名，以及按选项启用的压缩文件、文档和扩展包
+func isCandidateByName(path string, cfg *config.AppConfig, extensions map[string]bool) bool {
+	return extensions[strings.ToLower(filepath.Ext(path))] ||
+		(cfg.SniffGzip && isCompressedTextFile(path, extensions)) ||
+		(cfg.ScanDocs && isDocumentFile(path)) ||
+		(cfg.ScanExtensions && isExtensionPackage(path))
+}
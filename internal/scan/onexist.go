@@ -0,0 +1,110 @@
+package scan
+
+import (
+	"fmt"
+	"jsleaksscan/internal/config"
+	"jsleaksscan/internal/i18n"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// 结果文件已存在时的处理方式 (-on-exist)
+const (
+	onExistSkip      = "skip"      // 不写入，按来源输出时不再扫描该来源
+	onExistAppend    = "append"    // 追加到已有文件 (默认)
+	onExistOverwrite = "overwrite" // 清空已有文件后写入
+	onExistRename    = "rename"    // 写入编号的新文件 (如 name-1.txt)
+)
+
+// outputFiles 决定结果文件在本次运行中实际写入的路径 (-on-exist)。
+// 只有本次运行第一次写入某个文件时才检查它是否已存在，之后同一文件的写入沿用第一次的决定，
+// 因此本次运行自己创建的文件 (例如 -stream-findings 分批写入) 总是追加。
+// 检查和决定在同一把锁内完成，并发的 worker 不会对同一个文件重复判断；
+// overwrite 在锁内清空文件，rename 在锁内创建空的编号文件以占用该文件名
+type outputFiles struct {
+	mode     string
+	verbose  bool
+	mu       sync.Mutex
+	resolved map[string]string // 结果路径 -> 实际写入的路径，空字符串表示跳过 (skip)
+}
+
+func newOutputFiles(cfg *config.AppConfig) *outputFiles {
+	return &outputFiles{mode: cfg.OnExist, verbose: !cfg.Quiet && cfg.Verbose, resolved: make(map[string]string)}
+}
+
+// resolve 返回结果路径在本次运行中实际写入的路径，ok 为 false 时表示文件已存在且应跳过
+func (o *outputFiles) resolve(path string) (actual string, ok bool, err error) {
+	if o.mode == onExistAppend {
+		return path, true, nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if actual, seen := o.resolved[path]; seen {
+		return actual, actual != "", nil
+	}
+
+	actual = path
+	if _, statErr := os.Stat(path); statErr == nil {
+		switch o.mode {
+		case onExistSkip:
+			actual = ""
+			if o.verbose {
+				i18n.Printf("结果文件 '%s' 已存在，跳过 (-on-exist skip)。\n", path)
+			}
+		case onExistOverwrite:
+			if err := os.Truncate(path, 0); err != nil {
+				return "", false, fmt.Errorf("清空已有的结果文件 '%s' 失败: %w", path, err)
+			}
+		case onExistRename:
+			if actual, err = reserveNumberedPath(path); err != nil {
+				return "", false, err
+			}
+			if o.verbose {
+				i18n.Printf("结果文件 '%s' 已存在，改为写入 '%s' (-on-exist rename)。\n", path, actual)
+			}
+		}
+	}
+	o.resolved[path] = actual
+	return actual, actual != "", nil
+}
+
+// writesTextFiles 判断文本结果文件是否为本次运行的输出: -socket 时不写入结果文件，
+// -findings-only 时发现打印到标准输出，结果文件只是附带写入的副本
+func (rw *resultWriter) writesTextFiles() bool {
+	return rw.socket == nil && !rw.cfg.FindingsOnly
+}
+
+// reserveNumberedPath 创建 path 的第一个不存在的编号变体 (name-1.txt、name-2.txt ...) 并返回其路径
+func reserveNumberedPath(path string) (string, error) {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for n := 1; ; n++ {
+		candidate := fmt.Sprintf("%s-%d%s", base, n, ext)
+		file, err := os.OpenFile(candidate, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("创建结果文件 '%s' 失败: %w", candidate, err)
+		}
+		file.Close()
+		return candidate, nil
+	}
+}
+
+// skipSource 判断是否不再扫描该来源: 结果文件总大小已达到 -max-output-size 上限，或结果文件已存在 (-on-exist skip)。
+// 后者只在每个来源对应一个结果文件时适用；按严重级别、规则或主机汇总输出时，已存在的汇总文件只是不再写入。
+// 文本结果文件不是本次运行的输出时 (-socket 代替结果文件，-findings-only 将发现打印到标准输出) 不因已有的结果文件跳过来源
+func (rw *resultWriter) skipSource(source string) bool {
+	if rw.limit.exceeded() {
+		return true
+	}
+	cfg := rw.cfg
+	if cfg.OnExist != onExistSkip || !rw.writesTextFiles() || cfg.BySeverity || cfg.ByRule || cfg.GroupByHost || cfg.HARInput != "" {
+		return false
+	}
+	_, ok, err := rw.outputs.resolve(outputPathFor(cfg, ScanResult{Source: source}))
+	return err == nil && !ok
+}
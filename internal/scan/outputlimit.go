@@ -0,0 +1,53 @@
+package scan
+
+import (
+	"fmt"
+	"jsleaksscan/internal/i18n"
+	"sync/atomic"
+)
+
+// outputLimit 限制本次运行写入结果文件的总字节数 (-max-output-size)，防止大量或超长的发现 (例如 PEM 私钥块) 写满磁盘。
+// 计入文本结果文件和 NDJSON 文件的写入量；一批发现总是完整写入，因此总大小可能略超出上限。
+// 达到上限后不再写入任何发现，尚未扫描的来源被跳过 (见 resultWriter.skipSource)；nil 表示不限制
+type outputLimit struct {
+	limit   int64
+	written atomic.Int64 // 已写入结果文件的字节数
+	reached atomic.Bool
+	dropped atomic.Int64 // 达到上限后未写入的发现数
+}
+
+func newOutputLimit(limitMB int) *outputLimit {
+	if limitMB <= 0 {
+		return nil
+	}
+	return &outputLimit{limit: int64(limitMB) * 1024 * 1024}
+}
+
+// add 记录写入结果文件的字节数，第一次达到上限时打印警告
+func (l *outputLimit) add(n int) {
+	if l == nil || n == 0 {
+		return
+	}
+	if l.written.Add(int64(n)) >= l.limit && l.reached.CompareAndSwap(false, true) {
+		i18n.Printf("\n警告: 结果文件总大小已达到 -max-output-size 上限 (%d MB)，停止写入并取消剩余的扫描。\n", l.limit/(1024*1024))
+	}
+}
+
+// exceeded 判断是否已达到上限
+func (l *outputLimit) exceeded() bool {
+	return l != nil && l.reached.Load()
+}
+
+// drop 记录因达到上限而未写入的发现
+func (l *outputLimit) drop(findings int) {
+	l.dropped.Add(int64(findings))
+}
+
+// summary 在达到上限时报告上限前的写入量，并返回使扫描以非零状态退出的错误；未达到上限时返回 nil
+func (l *outputLimit) summary(written int64) error {
+	if !l.exceeded() {
+		return nil
+	}
+	i18n.Printf("达到 -max-output-size 上限前共写入 %.1f MB 结果 (%d 条发现)，之后的 %d 条发现未写入。\n", float64(l.written.Load())/(1024*1024), written, l.dropped.Load())
+	return fmt.Errorf("结果文件总大小达到 -max-output-size 上限 (%d MB)，扫描提前结束", l.limit/(1024*1024))
+}
@@ -0,0 +1,93 @@
+package scan
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"jsleaksscan/internal/config"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// maxPreflightBody 预检时读取的响应体大小上限
+const maxPreflightBody = 1024 * 1024
+
+// preflightScriptExtensions 预检时视为脚本或数据文件的扩展名，这类 URL 返回 HTML 页面通常说明请求被拦截
+var preflightScriptExtensions = map[string]bool{
+	".js": true, ".mjs": true, ".cjs": true, ".jsx": true, ".ts": true, ".json": true, ".map": true,
+}
+
+// preflightCheck 在大量请求开始前检查第一个 URL (及代理) 是否可用 (-preflight)：
+// 请求必须成功、状态码被接受、没有被重定向到其他主机、响应体不为空，脚本 URL 不能返回 HTML 页面；
+// 对脚本 URL 还会请求同一主机上一个不存在的路径，若返回与目标完全相同的内容，说明代理或网关对所有请求都返回同一页面 (如强制门户)
+func preflightCheck(client *http.Client, rawURL string, cfg *config.AppConfig) error {
+	target, _ := normalizeTargetURL(rawURL)
+	via := ""
+	if cfg.ScanOptions.Proxy != "" {
+		via = " (经代理)"
+	}
+
+	resp, body, err := preflightFetch(client, target, cfg)
+	if err != nil {
+		return fmt.Errorf("请求 '%s'%s 失败: %w", rawURL, via, err)
+	}
+	if !cfg.ScanOptions.AcceptsStatus(resp.StatusCode) {
+		return fmt.Errorf("'%s'%s 返回状态码 %d", rawURL, via, resp.StatusCode)
+	}
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		return err
+	}
+	if final := resp.Request.URL; !strings.EqualFold(final.Hostname(), targetURL.Hostname()) {
+		return fmt.Errorf("'%s'%s 被重定向到其他主机 '%s'，可能是代理或网关的登录/认证页面", rawURL, via, final.Redacted())
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return fmt.Errorf("'%s'%s 返回了空的响应体", rawURL, via)
+	}
+
+	if !preflightScriptExtensions[strings.ToLower(path.Ext(targetURL.Path))] {
+		return nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/html" {
+		return fmt.Errorf("脚本 URL '%s'%s 返回了 HTML 页面，可能被代理、WAF 或强制门户拦截", rawURL, via)
+	}
+
+	// 同一主机上一个随机的不存在路径返回相同内容，说明所有请求都得到同一页面
+	suffix := make([]byte, 8)
+	rand.Read(suffix)
+	probeURL := *targetURL
+	probeURL.Path = "/jsleaksscan-preflight-" + hex.EncodeToString(suffix) + path.Ext(targetURL.Path)
+	probeURL.RawPath = ""
+	probeURL.RawQuery = ""
+	probeResp, probeBody, err := preflightFetch(client, probeURL.String(), cfg)
+	if err == nil && cfg.ScanOptions.AcceptsStatus(probeResp.StatusCode) && bytes.Equal(probeBody, body) {
+		return fmt.Errorf("'%s'%s 与不存在的路径 '%s' 返回了完全相同的内容，代理或网关可能对所有请求都返回同一页面", rawURL, via, probeURL.Path)
+	}
+	return nil
+}
+
+// preflightFetch 以扫描请求相同的请求头发送 GET 请求，返回响应和 (最多 maxPreflightBody 字节的) 响应体
+func preflightFetch(client *http.Client, target string, cfg *config.AppConfig) (*http.Response, []byte, error) {
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", defaultUserAgent)
+	req.Header.Set("Accept", "*/*")
+	applyCustomHeaders(req, cfg.ScanOptions)
+	resp, err := doWithDNSRetry(client, req, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPreflightBody))
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取响应体失败: %w", err)
+	}
+	return resp, body, nil
+}
@@ -0,0 +1,100 @@
+package scan
+
+import (
+	"fmt"
+	"jsleaksscan/internal/i18n"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// progressPrinter 按固定间隔打印 URL 扫描进度，避免每完成一个 URL 就输出一次
+// 完成计数使用原子变量，处理 URL 的 goroutine 之间无需加锁
+// 标准输出是终端时，同一行还会显示最近的速率和按该速率估算的剩余时间
+type progressPrinter struct {
+	total     int
+	processed atomic.Int64
+	started   time.Time
+	rates     *rateTracker
+	tty       bool // 标准输出是否为终端，重定向到文件或管道时不显示速率和剩余时间
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// newProgressPrinter 创建并启动进度打印器，interval 为最短打印间隔
+func newProgressPrinter(total int, interval time.Duration) *progressPrinter {
+	started := time.Now()
+	p := &progressPrinter{
+		total:   total,
+		started: started,
+		rates:   newRateTracker(started),
+		tty:     isTerminal(os.Stdout),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go p.run(interval)
+	return p
+}
+
+// isTerminal 判断文件是否为终端 (字符设备)
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// add 记录完成了一个 URL
+func (p *progressPrinter) add() {
+	p.processed.Add(1)
+}
+
+func (p *progressPrinter) run(interval time.Duration) {
+	defer close(p.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := int64(-1)
+	for {
+		select {
+		case now := <-ticker.C:
+			current := p.processed.Load()
+			if p.tty {
+				// 终端上每次都刷新，请求变慢或停滞时速率和剩余时间也能及时更新
+				p.print(current, p.rates.rate(current, now))
+			} else if current != last {
+				// 进度没有变化时不重复打印
+				p.print(current, 0)
+			}
+			last = current
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// finish 停止定时打印，并打印最终进度 (终端上附带整个扫描的平均速率)
+func (p *progressPrinter) finish() {
+	close(p.stop)
+	<-p.done
+	processed := p.processed.Load()
+	i18n.Printf("\r进度: %d/%d (%.2f%%)", processed, p.total, float64(processed)*100/float64(p.total))
+	if p.tty {
+		if elapsed := time.Since(p.started).Seconds(); elapsed > 0 {
+			i18n.Printf(" 平均 %.1f 个/秒\033[K", float64(processed)/elapsed)
+		}
+	}
+	fmt.Println() // 换行，结束进度条打印
+}
+
+// print 打印进度行；终端上附加速率和剩余时间，并清除行尾上一次打印残留的字符
+func (p *progressPrinter) print(processed int64, rate float64) {
+	i18n.Printf("\r进度: %d/%d (%.2f%%)", processed, p.total, float64(processed)*100/float64(p.total))
+	if !p.tty {
+		return
+	}
+	eta := "--" // 还没有完成任何 URL 或最近没有进展时无法估算
+	if remaining := int64(p.total) - processed; remaining <= 0 {
+		eta = "0s"
+	} else if rate > 0 {
+		eta = time.Duration(float64(remaining) / rate * float64(time.Second)).Round(time.Second).String()
+	}
+	i18n.Printf(" %.1f 个/秒 剩余约 %s\033[K", rate, eta)
+}
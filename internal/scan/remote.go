@@ -0,0 +1,114 @@
+package scan
+
+import (
+	"fmt"
+	"jsleaksscan/internal/config"
+	"jsleaksscan/internal/httpclient"
+	"jsleaksscan/internal/i18n"
+	"jsleaksscan/internal/rules"
+	"jsleaksscan/internal/utils"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// FetchRemoteInputs 下载以 http(s):// 地址指定的配置文件 (-c、-severity-paths) 和 URL 列表 (-uf)，
+// 使用与扫描相同的代理和超时设置，下载的文件缓存在本地，对应的参数替换为缓存文件路径。
+// 在扫描开始前调用；下载失败且没有缓存时返回错误，有缓存时使用缓存并输出警告
+func FetchRemoteInputs(cfg *config.AppConfig) error {
+	inputs := []struct {
+		name string
+		path *string
+	}{
+		{"配置文件", &cfg.ConfigFile},
+		{"严重级别路径配置", &cfg.SeverityPaths},
+		{"URL 文件", &cfg.URLListFile},
+	}
+
+	fetcher := &remoteFetcher{opts: cfg.ScanOptions}
+	for _, input := range inputs {
+		if !utils.IsRemotePath(*input.path) || (input.path == &cfg.URLListFile && cfg.Mode != "urlScan") {
+			continue
+		}
+
+		remote := *input.path
+		local, stale, err := fetcher.fetch(remote)
+		if local == "" {
+			return fmt.Errorf("获取远程%s '%s' 失败: %w", input.name, remote, err)
+		}
+		if stale {
+			i18n.Printf("警告: 获取远程%s '%s' 失败 (%v)，使用之前的缓存: %s\n", i18n.T(input.name), remote, err, local)
+		} else if !cfg.Quiet {
+			i18n.Printf("已下载远程%s '%s'，缓存到: %s\n", i18n.T(input.name), remote, local)
+		}
+		remoteOrigins[local] = remote
+		*input.path = local
+	}
+	return nil
+}
+
+// remoteOrigins 记录 FetchRemoteInputs 下载的缓存文件对应的远程地址 (缓存路径 -> URL)，
+// 使远程配置文件中 includes 的相对路径相对于原 URL 解析
+var remoteOrigins = make(map[string]string)
+
+// RuleIncludeLoader 返回读取规则文件的 rules.IncludeLoader (见 rules.ResolveIncludes):
+// 本地文件直接读取，以 http(s):// 地址包含的文件与 -c 一样下载并缓存 (见 remoteFetcher)
+func RuleIncludeLoader(cfg *config.AppConfig) rules.IncludeLoader {
+	fetcher := &remoteFetcher{opts: cfg.ScanOptions}
+	return func(ref string) (string, string, error) {
+		location := ref
+		if utils.IsRemotePath(ref) {
+			local, stale, err := fetcher.fetch(ref)
+			if local == "" {
+				return "", "", fmt.Errorf("获取被包含的规则文件 '%s' 失败: %w", ref, err)
+			}
+			if stale {
+				i18n.Printf("警告: 获取被包含的规则文件 '%s' 失败 (%v)，使用之前的缓存: %s\n", ref, err, local)
+			}
+			ref = local
+		} else if origin, ok := remoteOrigins[ref]; ok {
+			location = origin
+		} else if abs, err := filepath.Abs(ref); err == nil {
+			location = abs
+		}
+		content, err := config.ReadConfigFile(ref)
+		if err != nil {
+			return "", "", err
+		}
+		return content, location, nil
+	}
+}
+
+// remoteFetcher 下载远程输入文件 (配置文件、URL 列表) 并缓存，HTTP 客户端在第一次下载时创建。
+// 只沿用扫描的代理和超时设置: -sni、-host-header 和 -H、-cookie 等请求头是针对扫描目标的，
+// 用于下载会导致 TLS 校验失败、请求发往错误的虚拟主机，或将扫描目标的凭据发送给文件所在的主机；
+// 需要认证的远程文件通过 -remote-header 和 -remote-auth 单独指定凭据
+type remoteFetcher struct {
+	opts   config.ScanOptions
+	client *http.Client
+}
+
+// fetch 下载 url 到本地缓存，返回值同 httpclient.FetchRemoteFile
+func (f *remoteFetcher) fetch(url string) (local string, stale bool, err error) {
+	if f.client == nil {
+		opts := f.opts
+		opts.SNI = ""
+		opts.HostHeader = ""
+		if f.client, err = httpclient.CreateHTTPClient(opts); err != nil {
+			return "", false, fmt.Errorf("创建 HTTP 客户端失败: %w", err)
+		}
+	}
+	return httpclient.FetchRemoteFile(f.client, url, remoteCacheDir(), func(req *http.Request) {
+		req.Header.Set("User-Agent", defaultUserAgent)
+		applyCustomHeaders(req, config.ScanOptions{Header: f.opts.RemoteHeader, Auth: f.opts.RemoteAuth})
+	})
+}
+
+// remoteCacheDir 返回远程文件的本地缓存目录 (用户缓存目录下的 jsleaksscan/remote)
+func remoteCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "jsleaksscan", "remote")
+}
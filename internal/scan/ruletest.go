@@ -0,0 +1,85 @@
+package scan
+
+import (
+	"fmt"
+	"jsleaksscan/internal/config"
+	"jsleaksscan/internal/i18n"
+	"jsleaksscan/internal/rules"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TestRules 使用编译好的规则匹配 -s 指定的单个字符串，打印命中的规则及其捕获组
+// 便于编写规则时快速验证，无需准备样例文件和执行完整扫描
+func TestRules(cfg *config.AppConfig, compiledRules *rules.CompiledRules) error {
+	input, source, err := readTestInput(cfg.TestInput)
+	if err != nil {
+		return err
+	}
+
+	results := processContent(source, input, compiledRules, cfg, false, nil)
+	if len(results) == 0 {
+		i18n.Printf("未命中任何规则 (输入 %d 字节)。\n", len(input))
+		return nil
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Offset != results[j].Offset {
+			return results[i].Offset < results[j].Offset
+		}
+		return results[i].Rule < results[j].Rule
+	})
+
+	matchedRules := make(map[string]bool)
+	for _, result := range results {
+		matchedRules[result.Rule] = true
+		severity := ""
+		if result.Severity != "" {
+			severity = fmt.Sprintf(" (%s)", result.Severity)
+		}
+		line := strconv.Itoa(result.Line)
+		if result.EndLine > 0 {
+			line += "-" + strconv.Itoa(result.EndLine)
+		}
+		i18n.Printf("[%s]%s 第 %s 行, 偏移 %d: %s\n", result.Rule, severity, line, result.Offset, result.Match)
+
+		// 字面量规则和外部匹配程序没有捕获组
+		re, ok := compiledRules.Regex[result.Rule]
+		if !ok || re.NumSubexp() == 0 {
+			continue
+		}
+		names := re.SubexpNames()
+		for _, loc := range re.FindAllSubmatchIndex(input, -1) {
+			if loc[0] != result.Offset {
+				continue
+			}
+			for group := 1; group < len(names); group++ {
+				label := fmt.Sprintf("%d", group)
+				if names[group] != "" {
+					label = fmt.Sprintf("%d (%s)", group, names[group])
+				}
+				if loc[2*group] < 0 {
+					i18n.Printf("    捕获组 %s: <未参与匹配>\n", label)
+					continue
+				}
+				i18n.Printf("    捕获组 %s: %s\n", label, input[loc[2*group]:loc[2*group+1]])
+			}
+			break
+		}
+	}
+	i18n.Printf("共 %d 处匹配, 命中 %d 条规则。\n", len(results), len(matchedRules))
+	return nil
+}
+
+// readTestInput 解析 -s 参数：以 @ 开头时读取对应文件的内容，否则直接使用参数值
+func readTestInput(value string) ([]byte, string, error) {
+	if path, ok := strings.CutPrefix(value, "@"); ok {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("读取测试输入文件 '%s' 失败: %w", path, err)
+		}
+		return content, path, nil
+	}
+	return []byte(value), "<input>", nil
+}
@@ -0,0 +1,336 @@
+package scan
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"jsleaksscan/internal/config"
+	"jsleaksscan/internal/httpclient"
+	"jsleaksscan/internal/i18n"
+	"jsleaksscan/internal/rules"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// sarifToolName 和 sarifInfoURI 是 SARIF 中的工具信息
+const (
+	sarifToolName = "JsLeaksScan"
+	sarifInfoURI  = "https://github.com/Warren-Jace/JsLeaksScan"
+)
+
+// sarifMaxResults 是单个 SARIF 文件 (一次上传) 中的发现数上限，与 GitHub 代码扫描对每个 run 的限制一致
+const sarifMaxResults = 25000
+
+// sarifLog 是 SARIF 2.1.0 日志的顶层结构 (只包含用到的字段)
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool              sarifTool               `json:"tool"`
+	AutomationDetails *sarifAutomationDetails `json:"automationDetails,omitempty"`
+	Results           []sarifResult           `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifAutomationDetails struct {
+	ID string `json:"id"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+	Properties           sarifRuleProps     `json:"properties"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifRuleProps struct {
+	Tags             []string `json:"tags"`
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]any    `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+// sarifLevels 和 sarifSecuritySeverities 将规则的严重级别映射为 SARIF 的 level 和 GitHub 的 security-severity (0-10)
+var (
+	sarifLevels             = map[string]string{"critical": "error", "high": "error", "medium": "warning", "low": "note", "info": "note"}
+	sarifSecuritySeverities = map[string]string{"critical": "9.5", "high": "8.0", "medium": "5.5", "low": "3.0", "info": "1.0"}
+)
+
+// sarifCollector 收集整次运行的发现，扫描结束时生成 SARIF (-sarif、-upload-github)。可被多个 goroutine 并发使用
+type sarifCollector struct {
+	mu      sync.Mutex
+	meta    map[string]rules.RuleMeta
+	root    string // 结果中的路径相对于该目录 (仓库根目录)
+	results []ScanResult
+}
+
+func newSARIFCollector(compiledRules *rules.CompiledRules, root string) *sarifCollector {
+	return &sarifCollector{meta: compiledRules.Meta, root: root}
+}
+
+// add 记录一个来源的发现
+func (c *sarifCollector) add(results []ScanResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results = append(c.results, results...)
+}
+
+// count 返回收集的发现数
+func (c *sarifCollector) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.results)
+}
+
+// logs 按来源和行号排序后生成 SARIF 日志，batchSize 大于 0 时每个日志最多 batchSize 条发现，否则只生成一个日志；
+// category 不为空时写入 automationDetails，分为多个日志时依次加上 /part-N 后缀，使各部分在代码扫描中作为不同的分析，不互相覆盖
+func (c *sarifCollector) logs(category string, batchSize int) []sarifLog {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	slices.SortStableFunc(c.results, func(a, b ScanResult) int {
+		return cmp.Or(cmp.Compare(a.Source, b.Source), cmp.Compare(a.Line, b.Line), cmp.Compare(a.Rule, b.Rule))
+	})
+
+	if batchSize <= 0 {
+		batchSize = max(1, len(c.results))
+	}
+	var logs []sarifLog
+	for start := 0; start == 0 || start < len(c.results); start += batchSize {
+		batch := c.results[start:min(start+batchSize, len(c.results))]
+		run := sarifRun{Tool: sarifTool{Driver: sarifDriver{Name: sarifToolName, InformationURI: sarifInfoURI, Rules: []sarifRule{}}}, Results: []sarifResult{}}
+		seenRules := make(map[string]bool)
+		for _, result := range batch {
+			if !seenRules[result.Rule] {
+				seenRules[result.Rule] = true
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, c.sarifRule(result))
+			}
+			run.Results = append(run.Results, sarifResultFor(result, c.root))
+		}
+		slices.SortFunc(run.Tool.Driver.Rules, func(a, b sarifRule) int { return cmp.Compare(a.ID, b.ID) })
+		logs = append(logs, sarifLog{Schema: "https://json.schemastore.org/sarif-2.1.0.json", Version: "2.1.0", Runs: []sarifRun{run}})
+	}
+	if category != "" {
+		for i := range logs {
+			id := category
+			if len(logs) > 1 {
+				id = fmt.Sprintf("%s/part-%d", category, i+1)
+			}
+			logs[i].Runs[0].AutomationDetails = &sarifAutomationDetails{ID: id}
+		}
+	}
+	return logs
+}
+
+// sarifRule 返回发现对应规则的 SARIF 规则描述
+func (c *sarifCollector) sarifRule(result ScanResult) sarifRule {
+	meta := c.meta[result.Rule]
+	description := meta.Description
+	if description == "" {
+		description = result.Rule
+	}
+	return sarifRule{
+		ID:                   result.Rule,
+		Name:                 result.Rule,
+		ShortDescription:     sarifMessage{Text: description},
+		DefaultConfiguration: sarifConfiguration{Level: sarifLevel(result.Severity)},
+		Properties: sarifRuleProps{
+			Tags:             append([]string{"security", "secret"}, meta.Tags...),
+			SecuritySeverity: sarifSecuritySeverities[result.Severity],
+		},
+	}
+}
+
+// sarifResultFor 将发现转换为 SARIF 结果。消息中的匹配内容只保留开头几个字符，避免密钥随代码扫描告警再次泄露
+func sarifResultFor(result ScanResult, root string) sarifResult {
+	location := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: sarifURI(root, result.Source)}}
+	if result.Line > 0 {
+		location.Region = &sarifRegion{StartLine: result.Line, EndLine: result.EndLine}
+	}
+	properties := map[string]any{"confidence": result.Confidence}
+	if result.Key != "" {
+		properties["key"] = result.Key
+	}
+	return sarifResult{
+		RuleID:              result.Rule,
+		Level:               sarifLevel(result.Severity),
+		Message:             sarifMessage{Text: fmt.Sprintf("%s: %s", result.Rule, maskMatch(result.Match))},
+		Locations:           []sarifLocation{{PhysicalLocation: location}},
+		PartialFingerprints: map[string]string{"jsleaksscan/v1": result.Fingerprint},
+		Properties:          properties,
+	}
+}
+
+func sarifLevel(severity string) string {
+	if level, ok := sarifLevels[severity]; ok {
+		return level
+	}
+	return "warning"
+}
+
+// sarifURI 返回来源相对于 root 的路径，使用 / 分隔；URL 来源和不在 root 下的来源使用原路径
+func sarifURI(root, source string) string {
+	if strings.Contains(source, "://") {
+		return source
+	}
+	if abs, err := filepath.Abs(source); err == nil {
+		if rel, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(source)
+}
+
+// maskMatch 只保留匹配内容的前 4 个字符，其余以 * 代替并注明长度
+func maskMatch(match string) string {
+	runes := []rune(match)
+	if len(runes) <= 4 {
+		return strings.Repeat("*", len(runes))
+	}
+	return fmt.Sprintf("%s**** (%d 个字符)", string(runes[:4]), len(runes))
+}
+
+// writeSARIF 将所有发现写入一个 SARIF 文件 (不分批)
+func (c *sarifCollector) writeSARIF(path string) error {
+	data, err := json.MarshalIndent(c.logs("", 0)[0], "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化 SARIF 失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入 SARIF 文件 '%s' 失败: %w", path, err)
+	}
+	return nil
+}
+
+// sarifRoot 返回 SARIF 中路径的基准目录: -d 所在的 git 仓库的根目录，不在仓库中时为当前目录
+func sarifRoot(cfg *config.AppConfig) string {
+	if cfg.LocalDir != "" {
+		if root, err := gitOutput(cfg.LocalDir, "rev-parse", "--show-toplevel"); err == nil {
+			return root
+		}
+	}
+	wd, _ := os.Getwd()
+	return wd
+}
+
+// githubUploadTarget 确定 -upload-github 的上传目标: 提交和引用依次取自 -upload-commit/-upload-ref、
+// GitHub Actions 的 GITHUB_SHA/GITHUB_REF 环境变量和 -d 所在 git 仓库的 HEAD；API 地址可由 GITHUB_API_URL 覆盖
+func githubUploadTarget(cfg *config.AppConfig) (httpclient.GitHubUpload, error) {
+	target := httpclient.GitHubUpload{
+		APIURL:    cmp.Or(os.Getenv("GITHUB_API_URL"), "https://api.github.com"),
+		Repo:      cfg.UploadGitHub,
+		Token:     cmp.Or(cfg.GitHubToken, os.Getenv("GITHUB_TOKEN")),
+		CommitSHA: cmp.Or(cfg.UploadCommit, os.Getenv("GITHUB_SHA")),
+		Ref:       cmp.Or(cfg.UploadRef, os.Getenv("GITHUB_REF")),
+	}
+	if target.Token == "" {
+		return target, fmt.Errorf("-upload-github 需要令牌: 使用 -github-token 或设置 GITHUB_TOKEN 环境变量")
+	}
+	var err error
+	if target.CommitSHA == "" {
+		if target.CommitSHA, err = gitOutput(cfg.LocalDir, "rev-parse", "HEAD"); err != nil {
+			return target, fmt.Errorf("无法确定上传的提交 (使用 -upload-commit 指定): %w", err)
+		}
+	}
+	if target.Ref == "" {
+		if target.Ref, err = gitOutput(cfg.LocalDir, "symbolic-ref", "HEAD"); err != nil {
+			return target, fmt.Errorf("无法确定上传的引用 (使用 -upload-ref 指定): %w", err)
+		}
+	}
+	return target, nil
+}
+
+// gitOutput 在 path (目录或文件所在的目录) 中执行 git 命令，返回去除首尾空白的输出
+func gitOutput(path string, args ...string) (string, error) {
+	dir := path
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("执行 git %s 失败: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// uploadGitHub 将所有发现分批 (每批最多 sarifMaxResults 条) 上传到 GitHub 代码扫描
+func (c *sarifCollector) uploadGitHub(cfg *config.AppConfig, target httpclient.GitHubUpload) error {
+	// 上传不受扫描的 -timeout 和 -sni 影响: 压缩后的 SARIF 可能较大，且目标是 GitHub 而非被扫描的主机
+	opts := cfg.ScanOptions
+	opts.SNI = ""
+	opts.Timeout = max(opts.Timeout, 60)
+	client, err := httpclient.CreateHTTPClient(opts)
+	if err != nil {
+		return err
+	}
+	logs := c.logs("jsleaksscan", sarifMaxResults)
+	for i, log := range logs {
+		data, err := json.Marshal(log)
+		if err != nil {
+			return fmt.Errorf("序列化 SARIF 失败: %w", err)
+		}
+		id, err := httpclient.UploadSARIF(client, target, data)
+		if err != nil {
+			if i > 0 {
+				return fmt.Errorf("第 %d/%d 批: %w", i+1, len(logs), err)
+			}
+			return err
+		}
+		if !cfg.Quiet {
+			i18n.Printf("SARIF 第 %d/%d 批 (%d 条发现) 已上传到 GitHub 代码扫描 (%s)，上传 ID: %s\n", i+1, len(logs), len(log.Runs[0].Results), target.Repo, id)
+		}
+	}
+	return nil
+}
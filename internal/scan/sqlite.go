@@ -0,0 +1,248 @@
+package scan
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"jsleaksscan/internal/rules"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite" // 纯 Go 实现的 SQLite 驱动，无需 CGO
+)
+
+// sqliteSchema 在数据库中创建发现表和常用查询的索引 (已存在时不做修改)，多次运行的发现追加到同一张表中，以 run_id 区分
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS findings (
+	id             INTEGER PRIMARY KEY AUTOINCREMENT,
+	run_id         TEXT NOT NULL,
+	found_at       TEXT NOT NULL,
+	source         TEXT NOT NULL,
+	rule           TEXT NOT NULL,
+	severity       TEXT,
+	description    TEXT,
+	tags           TEXT,
+	confidence     INTEGER,
+	match          TEXT NOT NULL,
+	match_encoding TEXT,
+	line           INTEGER,
+	end_line       INTEGER,
+	status         INTEGER,
+	final_url      TEXT,
+	fingerprint    TEXT NOT NULL,
+	metadata       TEXT,
+	tier           TEXT,
+	key_name       TEXT
+);
+CREATE INDEX IF NOT EXISTS findings_run_id ON findings (run_id);
+CREATE INDEX IF NOT EXISTS findings_rule ON findings (rule);
+CREATE INDEX IF NOT EXISTS findings_fingerprint ON findings (fingerprint);
+`
+
+const sqliteInsert = `INSERT INTO findings (run_id, found_at, source, rule, severity, description, tags, confidence, match, match_encoding, line, end_line, status, final_url, fingerprint, metadata, tier, key_name)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// sqliteMigrations 为旧版本创建的数据库补充之后新增的列 (列名 -> 定义)
+var sqliteMigrations = []struct{ column, definition string }{
+	{"metadata", "TEXT"},
+	{"tier", "TEXT"},
+	{"key_name", "TEXT"},
+}
+
+// sqliteBatchSize 和 sqliteFlushInterval 控制批量提交: 累积的发现达到该数量或距上次提交超过该间隔时在一个事务中写入
+const (
+	sqliteBatchSize     = 500
+	sqliteFlushInterval = time.Second
+)
+
+// sqliteWriter 将发现写入 SQLite 数据库 (-sqlite)，便于用 SQL 跨多次扫描查询和统计。
+// 所有写入由单独的 goroutine 完成，其他 goroutine 只把结果放入通道，避免 SQLite 的写锁竞争；
+// 写入按批在事务中提交，减少每条发现一次提交的开销
+type sqliteWriter struct {
+	path    string
+	db      *sql.DB
+	meta    map[string]rules.RuleMeta
+	batches chan []ScanResult
+	done    chan struct{}
+
+	mu      sync.Mutex // 保护 err 和 written
+	err     error      // 写入 goroutine 遇到的第一个错误，之后的发现不再写入
+	written int
+}
+
+// newSQLiteWriter 打开 (不存在时创建) 数据库并创建表结构，然后启动写入 goroutine
+func newSQLiteWriter(path string, compiledRules *rules.CompiledRules) (*sqliteWriter, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("打开 SQLite 数据库 '%s' 失败: %w", path, err)
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA busy_timeout = 5000;" + sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("创建 SQLite 数据库 '%s' 的表结构失败: %w", path, err)
+	}
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("升级 SQLite 数据库 '%s' 的表结构失败: %w", path, err)
+	}
+	w := &sqliteWriter{
+		path:    path,
+		db:      db,
+		meta:    compiledRules.Meta,
+		batches: make(chan []ScanResult, 64),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// migrateSQLite 为缺少新增列的 findings 表添加这些列，已有的发现在新列中为 NULL
+func migrateSQLite(db *sql.DB) error {
+	for _, migration := range sqliteMigrations {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('findings') WHERE name = ?", migration.column).Scan(&count); err != nil {
+			return err
+		}
+		if count == 0 {
+			if _, err := db.Exec("ALTER TABLE findings ADD COLUMN " + migration.column + " " + migration.definition); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// write 将一个来源的结果交给写入 goroutine；写入 goroutine 已出错时返回该错误
+func (w *sqliteWriter) write(results []ScanResult) error {
+	w.mu.Lock()
+	err := w.err
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if len(results) > 0 {
+		w.batches <- slices.Clone(results)
+	}
+	return nil
+}
+
+// run 是唯一的写入 goroutine: 累积发现并按批量大小或时间间隔提交，通道关闭后提交剩余的发现
+func (w *sqliteWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(sqliteFlushInterval)
+	defer ticker.Stop()
+	var pending []ScanResult
+	for {
+		select {
+		case results, ok := <-w.batches:
+			if !ok {
+				w.commit(pending)
+				return
+			}
+			pending = append(pending, results...)
+			if len(pending) >= sqliteBatchSize {
+				w.commit(pending)
+				pending = nil
+			}
+		case <-ticker.C:
+			w.commit(pending)
+			pending = nil
+		}
+	}
+}
+
+// commit 在一个事务中插入一批发现
+func (w *sqliteWriter) commit(results []ScanResult) {
+	w.mu.Lock()
+	failed := w.err != nil
+	w.mu.Unlock()
+	if len(results) == 0 || failed {
+		return
+	}
+	err := w.insert(results)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		w.err = fmt.Errorf("写入 SQLite 数据库 '%s' 失败: %w", w.path, err)
+		return
+	}
+	w.written += len(results)
+}
+
+func (w *sqliteWriter) insert(results []ScanResult) error {
+	tx, err := w.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(sqliteInsert)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, result := range results {
+		meta := w.meta[result.Rule]
+		if _, err := stmt.Exec(
+			result.RunID,
+			result.FoundAt.Format(time.RFC3339Nano),
+			result.Source,
+			result.Rule,
+			nullString(result.Severity),
+			nullString(meta.Description),
+			nullString(strings.Join(meta.Tags, ",")),
+			result.Confidence,
+			result.Match,
+			nullString(result.Encoding),
+			nullInt(result.Line),
+			nullInt(result.EndLine),
+			nullInt(result.Status),
+			nullString(result.FinalURL),
+			result.Fingerprint,
+			nullJSON(result.Metadata),
+			nullString(result.Tier),
+			nullString(result.Key),
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// nullString 和 nullInt 将空值写为 NULL，便于用 IS NULL 查询
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullInt(n int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(n), Valid: n != 0}
+}
+
+// nullJSON 将元信息写为 JSON 对象 (可用 json_extract 查询)，没有元信息时写为 NULL
+func nullJSON(metadata map[string]string) sql.NullString {
+	if len(metadata) == 0 {
+		return sql.NullString{}
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(data), Valid: true}
+}
+
+// Close 提交剩余的发现并关闭数据库，返回写入的发现数和写入过程中的错误
+func (w *sqliteWriter) Close() (int, error) {
+	close(w.batches)
+	<-w.done
+	closeErr := w.db.Close()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.written, w.err
+	}
+	if closeErr != nil {
+		return w.written, fmt.Errorf("关闭 SQLite 数据库 '%s' 失败: %w", w.path, closeErr)
+	}
+	return w.written, nil
+}
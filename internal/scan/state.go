@@ -0,0 +1,41 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// scanState 是增量扫描状态文件 (-state-file) 的内容
+type scanState struct {
+	LastScan time.Time `json:"last_scan"` // 上次扫描的开始时间
+}
+
+// loadScanState 读取增量扫描状态文件。文件不存在时返回零值状态 (即全量扫描)
+func loadScanState(path string) (scanState, error) {
+	var state scanState
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("读取状态文件 '%s' 失败: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("解析状态文件 '%s' 失败: %w", path, err)
+	}
+	return state, nil
+}
+
+// saveScanState 写入增量扫描状态文件
+func saveScanState(path string, state scanState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化扫描状态失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入状态文件 '%s' 失败: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package scan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"jsleaksscan/internal/i18n"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// rateWindow 计算当前速率时使用的时间窗口
+const rateWindow = 10 * time.Second
+
+// urlScanStats 记录 URL 扫描过程中的实时计数，可被多个 worker 并发更新
+type urlScanStats struct {
+	start     time.Time
+	total     int
+	inFlight  atomic.Int64
+	completed atomic.Int64
+	errors    atomic.Int64
+	dnsErrors atomic.Int64 // errors 中因 DNS 解析失败 (重试后仍失败) 的数量
+	findings  func() int64 // 已发现的结果数 (由结果输出器统计)
+
+	rates *rateTracker
+}
+
+func newURLScanStats(total int, findings func() int64) *urlScanStats {
+	start := time.Now()
+	return &urlScanStats{start: start, total: total, findings: findings, rates: newRateTracker(start)}
+}
+
+// currentRate 返回最近 rateWindow 内的平均完成速率 (个/秒)
+func (s *urlScanStats) currentRate() float64 {
+	return s.rates.rate(s.completed.Load(), time.Now())
+}
+
+// rateTracker 记录完成数的采样并计算最近 rateWindow 内的平均速率，可被多个 goroutine 并发使用
+type rateTracker struct {
+	start   time.Time
+	mu      sync.Mutex
+	samples []rateSample // 最近 rateWindow 内的完成数采样
+}
+
+type rateSample struct {
+	at        time.Time
+	completed int64
+}
+
+func newRateTracker(start time.Time) *rateTracker {
+	return &rateTracker{start: start}
+}
+
+// rate 记录 now 时的完成数，返回最近 rateWindow 内的平均速率 (个/秒)
+func (t *rateTracker) rate(completed int64, now time.Time) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.samples = append(t.samples, rateSample{at: now, completed: completed})
+	// 丢弃窗口之外的采样，但至少保留一个作为基准
+	for len(t.samples) > 2 && now.Sub(t.samples[1].at) >= rateWindow {
+		t.samples = t.samples[1:]
+	}
+	oldest := t.samples[0]
+	if oldest.at.Equal(now) {
+		oldest = rateSample{at: t.start}
+	}
+	elapsed := now.Sub(oldest.at).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(completed-oldest.completed) / elapsed
+}
+
+// statsSnapshot 是 -stats-addr 接口返回的 JSON
+type statsSnapshot struct {
+	InFlight       int64   `json:"in_flight"`
+	Completed      int64   `json:"completed"`
+	Total          int     `json:"total"`
+	Errors         int64   `json:"errors"`
+	DNSErrors      int64   `json:"dns_errors"` // errors 中 DNS 解析失败的数量
+	Findings       int64   `json:"findings"`
+	RatePerSec     float64 `json:"rate_per_sec"`     // 最近 10 秒的平均速率
+	AvgRatePerSec  float64 `json:"avg_rate_per_sec"` // 自扫描开始的平均速率
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+}
+
+func (s *urlScanStats) snapshot() statsSnapshot {
+	elapsed := time.Since(s.start).Seconds()
+	completed := s.completed.Load()
+	snap := statsSnapshot{
+		InFlight:       s.inFlight.Load(),
+		Completed:      completed,
+		Total:          s.total,
+		Errors:         s.errors.Load(),
+		DNSErrors:      s.dnsErrors.Load(),
+		Findings:       s.findings(),
+		RatePerSec:     s.currentRate(),
+		ElapsedSeconds: elapsed,
+	}
+	if elapsed > 0 {
+		snap.AvgRatePerSec = float64(completed) / elapsed
+	}
+	return snap
+}
+
+// startStatsServer 在 addr 上启动统计接口 (GET /stats 或 /)，返回用于关闭服务的函数
+// 监听失败时立即返回错误，避免扫描开始后才发现端口被占用
+func startStatsServer(addr string, stats *urlScanStats) (func(), error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("启动统计接口 '%s' 失败: %w", addr, err)
+	}
+
+	handler := func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(stats.snapshot())
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", handler)
+	mux.HandleFunc("/stats", handler)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			i18n.Printf("\n警告: 统计接口异常退出: %v\n", err)
+		}
+	}()
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}, nil
+}
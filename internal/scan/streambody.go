@@ -0,0 +1,235 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"jsleaksscan/internal/config"
+	"jsleaksscan/internal/i18n"
+	"jsleaksscan/internal/rules"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// streamBodyChunk 是 -stream-body 每次匹配前至少累积的新数据量
+const streamBodyChunk = 256 * 1024
+
+// bodyStreamScanner 在响应体下载过程中按窗口增量匹配 (-stream-body)。
+//
+// 窗口保留上一次匹配后的最后 overlap (-max-match-len) 字节: 长度达到 -max-match-len 的匹配本来就会被丢弃，
+// 因此跨越两次读取边界的匹配一定完整地出现在某个窗口中。每个匹配只由其起始偏移所在的范围负责报告:
+// 数据未结束时，窗口末尾 overlap 字节内开始的匹配可能随后续数据变长，留到下一个窗口报告。
+// 与 -split-large 的分块匹配相同，偏移和行号换算为整个响应体中的值
+type bodyStreamScanner struct {
+	source        string
+	windowCfg     config.AppConfig // 匹配每个窗口使用的配置: 不展开 data: URI，-first-only 由扫描器自己处理
+	compiledRules *rules.CompiledRules
+	overlap       int
+
+	buf         []byte // 当前窗口的内容，buf[0] 在响应体中的偏移为 bufStart
+	bufStart    int
+	owned       int // 起始偏移小于 owned 的匹配已经处理过
+	linesBefore int // bufStart 之前的换行数，用于换算行号
+	// reported 记录每个来源只报告一次的发现: 字面量规则只报告第一次出现 (键为规则名)，
+	// -jwt 的内置检测每个令牌、-endpoints 每个端点、-matcher 的每条发现只报告一次 (键为规则名和匹配内容)
+	reported map[string]bool
+	// maxPerRule 为 -max-matches-per-rule，按整个响应体而非每个窗口计数: perRule 记录每条正则规则已报告的匹配数，
+	// truncated 按出现顺序记录超出上限的规则
+	maxPerRule int
+	perRule    map[string]int
+	truncated  []string
+}
+
+func newBodyStreamScanner(source string, cfg *config.AppConfig, compiledRules *rules.CompiledRules) *bodyStreamScanner {
+	s := &bodyStreamScanner{source: source, windowCfg: *cfg, compiledRules: compiledRules, overlap: cfg.MaxMatchLen, reported: make(map[string]bool),
+		maxPerRule: cfg.MaxMatchesPerRule, perRule: make(map[string]int)}
+	s.windowCfg.DataURIs = false
+	s.windowCfg.FirstOnly = false
+	s.windowCfg.MaxMatchesPerRule = 0
+	return s
+}
+
+// feed 追加新读取的数据，eof 表示响应体已结束；返回新确定的发现 (偏移和行号已换算为整个响应体中的值)
+func (s *bodyStreamScanner) feed(data []byte, eof bool) []ScanResult {
+	s.buf = append(s.buf, data...)
+	end := s.bufStart + len(s.buf)
+	limit := end - s.overlap
+	if eof {
+		limit = end
+	}
+	if limit <= s.owned {
+		return nil
+	}
+
+	var batch []ScanResult
+	for _, result := range processContent(s.source, s.buf, s.compiledRules, &s.windowCfg, false, nil) {
+		offset := result.Offset + s.bufStart
+		if offset < s.owned || offset >= limit {
+			continue
+		}
+		if key, once := s.onceKey(result); once {
+			if s.reported[key] {
+				continue
+			}
+			s.reported[key] = true
+		}
+		if _, ok := s.compiledRules.Regex[result.Rule]; ok && s.maxPerRule > 0 {
+			if s.perRule[result.Rule] == s.maxPerRule {
+				s.truncated = append(s.truncated, result.Rule)
+			}
+			if s.perRule[result.Rule]++; s.perRule[result.Rule] > s.maxPerRule {
+				continue
+			}
+		}
+		result.Offset = offset
+		result.Line += s.linesBefore
+		if result.EndLine > 0 {
+			result.EndLine += s.linesBefore
+		}
+		batch = append(batch, result)
+	}
+	s.owned = limit
+
+	// 丢弃不再需要的数据，保留 owned 之前的 overlap 字节作为下一个窗口的上下文 (例如 \b 等边界断言)
+	if keepFrom := max(s.owned-s.overlap, s.bufStart); keepFrom > s.bufStart {
+		drop := keepFrom - s.bufStart
+		s.linesBefore += bytes.Count(s.buf[:drop], []byte("\n"))
+		s.buf = s.buf[:copy(s.buf, s.buf[drop:])]
+		s.bufStart = keepFrom
+	}
+	return batch
+}
+
+// onceKey 返回每个来源只报告一次的发现的去重键，与不分窗口匹配时的行为一致:
+// 端点提取 (-endpoints) 在同一来源内按端点去重，外部匹配程序 (-matcher) 对每个窗口运行一次，
+// 同一条发现会在多个窗口中重复出现，因此都按规则名和匹配内容去重
+func (s *bodyStreamScanner) onceKey(result ScanResult) (string, bool) {
+	if _, ok := s.compiledRules.Literal[result.Rule]; ok {
+		return result.Rule, true
+	}
+	if _, ok := s.compiledRules.Meta[result.Rule]; ok {
+		if _, ok := s.compiledRules.Regex[result.Rule]; ok || s.windowCfg.Matcher == "" {
+			return "", false // 正则规则的每处匹配分别报告
+		}
+		return result.Rule + "\x00" + result.Match, true // 外部匹配程序使用了与配置中的规则同名的规则名
+	}
+	// 不在规则配置中的规则名: -jwt、-endpoints 的内置检测或外部匹配程序的发现
+	return result.Rule + "\x00" + result.Match, true
+}
+
+// processURLStreaming 边下载边匹配响应体 (-stream-body)，发现在下载完成前即写出；
+// 与 -first-only 同时使用时，得到第一个发现后立即停止下载。未声明 Content-Length 时，
+// 已读取的数据达到 -min-body-size 之前的发现暂不写出，响应体小于 -min-body-size 时与 processURL 一样跳过。返回值与 processURL 相同
+func processURLStreaming(originalURL string, req *http.Request, resp *http.Response, cfg *config.AppConfig, compiledRules *rules.CompiledRules, memory *memoryBudget, out *resultWriter, started time.Time) urlOutcome {
+	// 只需要为窗口预留内存，而不是整个响应体
+	reserved, err := memory.acquire(req.Context(), int64(streamBodyChunk+2*cfg.MaxMatchLen), originalURL)
+	if err != nil {
+		i18n.Printf("错误: 等待 URL '%s' 的内存预算失败: %v\n", originalURL, err)
+		return outcomeFailed
+	}
+	defer func() { memory.release(reserved) }()
+
+	maxBodySize := cfg.ScanOptions.MaxBodySize
+	var body io.Reader = resp.Body
+	// 按魔数识别缺少 Content-Encoding 的 gzip 数据 (-sniff-gzip)，边下载边解压
+	if cfg.SniffGzip {
+		peeked := bufio.NewReader(resp.Body)
+		if magic, _ := peeked.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+			gz, err := gzip.NewReader(peeked)
+			if err != nil {
+				i18n.Printf("错误: 解析 URL '%s' 响应体的 gzip 头失败: %v\n", originalURL, err)
+				return outcomeFailed
+			}
+			defer gz.Close()
+			body = gz
+			if !cfg.Quiet && cfg.Verbose {
+				i18n.Printf("URL '%s' 的响应体为 gzip 数据，边下载边解压。\n", originalURL)
+			}
+		} else {
+			body = peeked
+		}
+	}
+	limited := io.LimitReader(body, maxBodySize)
+
+	finalURL := resp.Request.URL.String()
+	sink, finish := out.streamWriter(func(result *ScanResult) {
+		result.Status = resp.StatusCode
+		result.FinalURL = finalURL
+	})
+	scanner := newBodyStreamScanner(originalURL, cfg, compiledRules)
+	// 响应体大小未知时 (ContentLength < 0)，达到 -min-body-size 之前的发现保存在 held 中
+	minBodySize := cfg.ScanOptions.MinBodySize
+	var held []ScanResult
+	var results []ScanResult
+	size := 0
+	chunk := make([]byte, 32*1024)
+	pending := make([]byte, 0, streamBodyChunk)
+	stopped := false
+	for !stopped {
+		n, readErr := limited.Read(chunk)
+		pending = append(pending, chunk[:n]...)
+		size += n
+		eof := errors.Is(readErr, io.EOF)
+		if readErr != nil && !eof {
+			i18n.Printf("错误: 读取 URL '%s' 响应体失败: %v\n", originalURL, readErr)
+			return outcomeFailed
+		}
+		if len(pending) < streamBodyChunk && !eof {
+			continue
+		}
+		batch := scanner.feed(pending, eof)
+		pending = pending[:0]
+		if resp.ContentLength < 0 && int64(size) < minBodySize {
+			if eof {
+				if !cfg.Quiet && cfg.Verbose {
+					i18n.Printf("URL '%s' 的响应体 (%d 字节) 小于 -min-body-size，已跳过。\n", originalURL, size)
+				}
+				return outcomeOK
+			}
+			held = append(held, batch...)
+			continue
+		}
+		if len(held) > 0 {
+			batch, held = append(held, batch...), nil
+		}
+		if len(batch) > 0 {
+			if cfg.FirstOnly {
+				batch = batch[:1]
+				stopped = true // 不再读取剩余的响应体
+			}
+			sink(batch)
+			results = append(results, batch...)
+		}
+		if eof {
+			break
+		}
+	}
+	if !stopped {
+		if n, _ := body.Read(make([]byte, 1)); n > 0 {
+			i18n.Printf("警告: URL '%s' 的响应体超过 %d 字节限制，只处理了部分内容。\n", originalURL, maxBodySize)
+		}
+	} else if !cfg.Quiet && cfg.Verbose {
+		i18n.Printf("URL '%s' 已得到第一个发现 (-first-only)，停止下载 (已读取 %d 字节)。\n", originalURL, size)
+	}
+	if !cfg.Quiet {
+		for _, ruleName := range scanner.truncated {
+			i18n.Printf("提示: 规则 '%s' 在 '%s' 中的匹配超过 %d 处，只记录了前 %d 处 (+更多，见 -max-matches-per-rule)。\n", ruleName, originalURL, cfg.MaxMatchesPerRule, cfg.MaxMatchesPerRule)
+		}
+	}
+	out.recordSource(originalURL, size, len(results), started)
+
+	if len(results) > 0 {
+		outputFilePaths, err := finish(results)
+		if err != nil {
+			i18n.Printf("错误: %v\n", err)
+		} else if !cfg.Quiet && len(outputFilePaths) > 0 {
+			i18n.Printf("发现敏感信息 [%s] -> %s\n", originalURL, strings.Join(outputFilePaths, ", "))
+		}
+	} else if !cfg.Quiet && cfg.Verbose {
+		i18n.Printf("URL '%s' 未发现匹配项。\n", originalURL)
+	}
+	return outcomeOK
+}
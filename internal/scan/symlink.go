@@ -0,0 +1,77 @@
+package scan
+
+import (
+	"jsleaksscan/internal/config"
+	"jsleaksscan/internal/i18n"
+	"os"
+	"path/filepath"
+)
+
+// walkFollowingSymlinks 遍历 -d 指定的目录并跟随符号链接 (-follow-symlinks)，对每一项调用 visit (语义同 filepath.WalkFunc)。
+// 传给 visit 的 FileInfo 是符号链接指向的目标的信息；失效的符号链接以错误形式交给 visit。
+//
+// 防止循环: 每个目录在进入前解析为真实路径 (解析所有符号链接后的绝对路径)，已访问过的真实路径不再进入。
+// 指向祖先目录的符号链接因此不会造成无限递归，多个符号链接 (或符号链接与原目录) 指向同一目录时该目录也只扫描一次。
+// visit 返回 filepath.SkipAll 时停止遍历并返回 nil
+func walkFollowingSymlinks(cfg *config.AppConfig, visit filepath.WalkFunc) error {
+	visited := make(map[string]bool)
+
+	var walk func(path string, info os.FileInfo) error
+	walk = func(path string, info os.FileInfo) error {
+		if !info.IsDir() {
+			return visit(path, info, nil)
+		}
+
+		realPath, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			realPath, err = filepath.Abs(realPath)
+		}
+		if err != nil {
+			return visit(path, info, err)
+		}
+		if visited[realPath] {
+			if !cfg.Quiet && cfg.Verbose {
+				i18n.Printf("跳过目录 (已通过其他路径或符号链接扫描过 '%s'): %s\n", realPath, path)
+			}
+			return nil
+		}
+		visited[realPath] = true
+
+		if err := visit(path, info, nil); err != nil {
+			if err == filepath.SkipDir {
+				return nil
+			}
+			return err
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return visit(path, info, err)
+		}
+		for _, entry := range entries {
+			child := filepath.Join(path, entry.Name())
+			// os.Stat 跟随符号链接，得到目标的类型和大小
+			childInfo, err := os.Stat(child)
+			if err != nil {
+				if err := visit(child, nil, err); err != nil && err != filepath.SkipDir {
+					return err
+				}
+				continue
+			}
+			if err := walk(child, childInfo); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	rootInfo, err := os.Stat(cfg.LocalDir)
+	if err != nil {
+		err = visit(cfg.LocalDir, nil, err)
+	} else {
+		err = walk(cfg.LocalDir, rootInfo)
+	}
+	if err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,31 @@
+package scan
+
+import (
+	"jsleaksscan/internal/config"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWalkFollowingSymlinksSkipAll(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.js", "b.js", "c.js"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var files int
+	err := walkFollowingSymlinks(&config.AppConfig{LocalDir: dir}, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		files++
+		return filepath.SkipAll
+	})
+	if err != nil {
+		t.Fatalf("SkipAll 被当作错误返回: %v", err)
+	}
+	if files != 1 {
+		t.Errorf("返回 SkipAll 后又访问了文件: 共访问 %d 个", files)
+	}
+}
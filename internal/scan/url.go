@@ -2,20 +2,30 @@ package scan
 
 import (
 	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"jsleaksscan/internal/config"
 	"jsleaksscan/internal/httpclient"
+	"jsleaksscan/internal/i18n"
 	"jsleaksscan/internal/rules"
+	"jsleaksscan/internal/utils"
+	"net"
 	"net/http"
-	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 )
 
+// defaultUserAgent 为未指定 -ua 时请求使用的 User-Agent
+const defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/108.0.0.0 Safari/537.36"
+
 // ScanURLs 启动 URL 扫描
 func ScanURLs(cfg *config.AppConfig, compiledRules *rules.CompiledRules) error {
 	startTime := time.Now()
@@ -26,74 +36,273 @@ func ScanURLs(cfg *config.AppConfig, compiledRules *rules.CompiledRules) error {
 		return fmt.Errorf("创建 HTTP 客户端失败: %w", err)
 	}
 
-	// 准备 URL 列表
+	// 以 HAR 格式记录所有请求和响应 (-har)，便于排查认证、重定向和拦截等导致无结果的原因
+	var har *httpclient.HARRecorder
+	if cfg.HARFile != "" {
+		har, err = httpclient.NewHARRecorder(cfg.HARFile, cfg.HARBodies)
+		if err != nil {
+			return err
+		}
+		defer har.Close()
+		client.Transport = har.Wrap(client.Transport)
+	}
+
+	// 加载请求头文件 (-headers-file)，对所有请求生效
+	if cfg.ScanOptions.HeadersFile != "" {
+		headers, err := readHeadersFile(cfg.ScanOptions.HeadersFile)
+		if err != nil {
+			return fmt.Errorf("读取请求头文件 '%s' 失败: %w", cfg.ScanOptions.HeadersFile, err)
+		}
+		cfg.ScanOptions.FileHeaders = headers
+		if !cfg.Quiet {
+			i18n.Printf("从文件 '%s' 加载了 %d 个请求头。\n", cfg.ScanOptions.HeadersFile, len(headers))
+		}
+	}
+
+	// 执行登录流程 (-login)，会话 Cookie 保存在客户端中，令牌请求头附加到所有扫描请求
+	if cfg.ScanOptions.LoginFile != "" {
+		loginCfg, err := httpclient.LoadLoginConfig(cfg.ScanOptions.LoginFile)
+		if err != nil {
+			return fmt.Errorf("读取登录配置 '%s' 失败: %w", cfg.ScanOptions.LoginFile, err)
+		}
+		headers, err := httpclient.Login(client, loginCfg, func(req *http.Request) {
+			applyCustomHeaders(req, cfg.ScanOptions)
+		})
+		if err != nil {
+			return fmt.Errorf("登录失败: %w", err)
+		}
+		cfg.ScanOptions.LoginHeaders = headers
+		if !cfg.Quiet {
+			i18n.Printf("登录完成: 执行了 %d 个登录步骤，%d 个请求头将附加到所有请求。\n", len(loginCfg.Steps), len(headers))
+		}
+	}
+
+	// 准备 URL 列表：-uf 和 -u 可以同时指定，单个 URL 会合并到文件列表中
 	urlsToScan := []string{}
-	if cfg.SingleURL != "" {
-		urlsToScan = append(urlsToScan, strings.TrimSpace(cfg.SingleURL))
-		fmt.Printf("开始扫描单个 URL: %s (并发度: 1)\n", cfg.SingleURL)
-		cfg.ThreadNum = 1 // 单个 URL 不需要高并发
-	} else if cfg.URLListFile != "" {
-		fmt.Printf("开始从文件扫描 URL: %s (并发度: %d)\n", cfg.URLListFile, cfg.ThreadNum)
+	if cfg.URLListFile != "" {
 		fileURLs, err := readURLsFromFile(cfg.URLListFile)
 		if err != nil {
 			return fmt.Errorf("读取 URL 文件 '%s' 失败: %w", cfg.URLListFile, err)
 		}
 		if len(fileURLs) == 0 {
-			fmt.Println("警告: URL 文件为空，没有 URL 需要扫描。")
-			return nil
+			i18n.Println("警告: URL 文件为空。")
+		} else {
+			i18n.Printf("从文件 '%s' 加载了 %d 个 URL。\n", cfg.URLListFile, len(fileURLs))
 		}
 		urlsToScan = fileURLs
-		fmt.Printf("从文件 '%s' 加载了 %d 个 URL。\n", cfg.URLListFile, len(urlsToScan))
+	}
+	if singleURL := strings.TrimSpace(cfg.SingleURL); singleURL != "" {
+		if slices.Contains(urlsToScan, singleURL) {
+			if !cfg.Quiet && cfg.Verbose {
+				i18n.Printf("URL '%s' 已在 URL 文件中，不再重复添加。\n", singleURL)
+			}
+		} else {
+			urlsToScan = append(urlsToScan, singleURL)
+		}
+	}
+
+	// 按字典为每个主机生成候选路径，发现未被页面引用的 JS 文件；只有状态码被接受 (-accept-status，默认 2xx) 的候选 URL 会被扫描
+	if cfg.FuzzPaths != "" {
+		words, err := readURLsFromFile(cfg.FuzzPaths)
+		if err != nil {
+			return fmt.Errorf("读取路径字典 '%s' 失败: %w", cfg.FuzzPaths, err)
+		}
+		var generated int
+		urlsToScan, generated = expandFuzzPaths(urlsToScan, words)
+		i18n.Printf("路径字典 '%s' 生成了 %d 个候选 URL。\n", cfg.FuzzPaths, generated)
+	}
+
+	// 枚举存储桶中的对象 (-bucket)，对象 URL 加入扫描列表
+	if cfg.BucketURL != "" {
+		objects, skipped, err := listBucketObjects(client, cfg.BucketURL, cfg)
+		if err != nil {
+			return fmt.Errorf("枚举存储桶 '%s' 失败: %w", cfg.BucketURL, err)
+		}
+		i18n.Printf("存储桶 '%s' 中有 %d 个待扫描的对象 (跳过 %d 个非文本或大小超出限制的对象)。\n", cfg.BucketURL, len(objects), skipped)
+		seen := make(map[string]bool, len(urlsToScan))
+		for _, u := range urlsToScan {
+			seen[u] = true
+		}
+		for _, object := range objects {
+			if !seen[object] {
+				urlsToScan = append(urlsToScan, object)
+			}
+		}
+	}
+
+	// 本次扫描的并发度: 不修改 cfg.ThreadNum，避免之后读取配置的代码看到被改写的值。
+	// URL 列表在扫描开始前已全部确定，只启动 min(并发度, URL 数) 个 worker，因此 -u 扫描单个 URL 时只有一个 worker；
+	// 单个 URL 没有其他可以并发的请求，-t 在扫描多个 URL (-uf、-fuzz-paths、-bucket) 时生效
+	concurrency := cfg.ThreadNum
+	if cfg.URLListFile == "" && cfg.SingleURL != "" && cfg.FuzzPaths == "" && cfg.BucketURL == "" {
+		i18n.Printf("开始扫描单个 URL: %s (并发度: %d)\n", cfg.SingleURL, min(concurrency, len(urlsToScan)))
+	} else if len(urlsToScan) == 0 {
+		i18n.Println("警告: 没有 URL 需要扫描。")
+		return nil
 	} else {
-		//理论上 config 解析时已处理此情况，但作为防御性编程
-		return fmt.Errorf("内部错误：缺少 URL 来源 (既无单个 URL 也无 URL 文件)")
+		i18n.Printf("开始扫描 %d 个 URL (并发度: %d)\n", len(urlsToScan), concurrency)
 	}
 
-	// 使用 WaitGroup 和信号量控制并发
-	var wg sync.WaitGroup
-	urlSemaphore := make(chan struct{}, cfg.ThreadNum)
-	processedCount := 0
-	var countMutex sync.Mutex // 保护 processedCount
+	// 预检第一个 URL (-preflight)，代理或目标配置错误时在发出大量请求前中止
+	if cfg.Preflight {
+		if err := preflightCheck(client, urlsToScan[0], cfg); err != nil {
+			return fmt.Errorf("预检失败，扫描未开始: %w", err)
+		}
+		if !cfg.Quiet {
+			i18n.Printf("预检通过: %s\n", urlsToScan[0])
+		}
+	}
+
+	out, err := newResultWriter(cfg, compiledRules)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	// 响应体内容索引：同一 CDN 文件常以不同查询参数出现，内容相同的响应体只扫描一次
+	// -bloom 时响应体哈希和 URL 都改用固定内存的布隆过滤器去重，重复的 URL 不再请求
+	var contentBloom, urlBloom *bloomFilter
+	if cfg.Bloom {
+		contentBloom = newBloomFilter(cfg.BloomItems, cfg.BloomFPRate)
+		urlBloom = newBloomFilter(cfg.BloomItems, cfg.BloomFPRate)
+		if !cfg.Quiet {
+			i18n.Printf("布隆过滤器去重已启用: 预期 %d 项，误报率 %g，响应体和 URL 各一个过滤器，每个占用 %.1f MB\n",
+				cfg.BloomItems, cfg.BloomFPRate, float64(contentBloom.sizeBytes())/(1024*1024))
+		}
+	}
+	bodies := newContentIndex(contentBloom)
 
-	// 遍历 URL 并启动 goroutine 处理
+	// 所有 worker 共享的响应体内存预算 (-max-memory)
+	memory := newMemoryBudget(int64(cfg.ScanOptions.MaxMemory)*1024*1024, !cfg.Quiet && cfg.Verbose)
+	if memory != nil && !cfg.Quiet {
+		i18n.Printf("响应体内存预算: %d MB (所有 worker 共享)\n", cfg.ScanOptions.MaxMemory)
+	}
+
+	// 固定数量的 worker 从 URL 通道中取任务，协程数量与列表大小无关；
+	// 可调整容量的信号量 (limiter) 在 -adaptive 时进一步限制同时进行的请求数
+	var wg sync.WaitGroup
+	limiter := newConcurrencyLimiter(concurrency, cfg.Adaptive, !cfg.Quiet && cfg.Verbose)
+	if cfg.Adaptive && !cfg.Quiet {
+		i18n.Printf("自适应并发已启用: 初始并发度 %d，上限 %d\n", limiter.currentLimit(), concurrency)
+	}
+	// 实时统计 (可通过 -stats-addr 以 JSON 形式查看)
 	totalURLs := len(urlsToScan)
-	for _, u := range urlsToScan {
-		if u == "" { // 跳过空行
-			countMutex.Lock()
-			processedCount++
-			countMutex.Unlock()
-			continue
+	stats := newURLScanStats(totalURLs, out.findingCount)
+	if cfg.StatsAddr != "" {
+		stopStats, err := startStatsServer(cfg.StatsAddr, stats)
+		if err != nil {
+			return err
+		}
+		defer stopStats()
+		if !cfg.Quiet {
+			i18n.Printf("统计接口已启动: http://%s/stats\n", cfg.StatsAddr)
 		}
+	}
+
+	// 进度由独立的 goroutine 按 -progress-interval 定时打印
+	var progress *progressPrinter
+	if !cfg.Quiet {
+		progress = newProgressPrinter(totalURLs, cfg.ProgressInterval)
+	}
+
+	// URL 通道
+	urlQueue := make(chan string, concurrency*2) // 缓冲区大小
+
+	// 启动 URL 处理 workers (URL 数量少于并发度时不启动多余的 worker)
+	for i := 0; i < min(concurrency, totalURLs); i++ {
 		wg.Add(1)
-		urlSemaphore <- struct{}{} // 获取信号量
-		go func(targetURL string) {
-			defer func() {
-				<-urlSemaphore // 释放信号量
-				wg.Done()
-				countMutex.Lock()
-				processedCount++
-				if !cfg.Quiet {
-					// 打印进度
-					fmt.Printf("\r进度: %d/%d (%.2f%%)", processedCount, totalURLs, float64(processedCount)*100/float64(totalURLs))
+		go func(workerID int) {
+			defer wg.Done()
+			for targetURL := range urlQueue {
+				if !cfg.Quiet && cfg.Verbose {
+					i18n.Printf("[Worker %d] 开始处理: %s\n", workerID, targetURL)
 				}
-				countMutex.Unlock()
-			}()
-			processURL(targetURL, cfg, compiledRules, client)
-		}(u)
+				processQueuedURL(targetURL, cfg, compiledRules, client, bodies, memory, out, limiter, stats)
+				if progress != nil {
+					progress.add()
+				}
+			}
+		}(i)
+	}
+
+	// 将 URL 放入队列，队列满时阻塞，直到有 worker 空闲
+	var skippedURLs int
+	for _, u := range urlsToScan {
+		// 跳过空行和 (可能) 已出现过的 URL
+		if u == "" || (urlBloom != nil && urlBloom.addString(u)) {
+			if u != "" {
+				skippedURLs++
+				stats.completed.Add(1)
+			}
+			if progress != nil {
+				progress.add()
+			}
+			continue
+		}
+		urlQueue <- u
 	}
+	close(urlQueue)
 
 	// 等待所有 URL 处理完成
 	wg.Wait()
-	if !cfg.Quiet {
-		fmt.Println() // 换行，结束进度条打印
+	if progress != nil {
+		progress.finish()
+	}
+	if cfg.Adaptive && !cfg.Quiet {
+		i18n.Printf("自适应并发: 结束时并发度为 %d\n", limiter.currentLimit())
+	}
+	if dnsErrors := stats.dnsErrors.Load(); dnsErrors > 0 {
+		i18n.Printf("%d 个 URL 因 DNS 解析失败 (重试 %d 次后) 未能请求，可能是域名不存在或 DNS 服务器不稳定。\n", dnsErrors, cfg.ScanOptions.DNSRetries)
+	}
+	if skippedURLs > 0 {
+		i18n.Printf("布隆过滤器判定 %d 个 URL 已出现过，未发送请求。\n", skippedURLs)
+	}
+	if duplicates := bodies.duplicateCount(); duplicates > 0 {
+		i18n.Printf("跳过 %d 个与已扫描响应体内容相同的 URL。\n", duplicates)
 	}
-	fmt.Printf("URL 扫描完成。总耗时: %v\n", time.Since(startTime))
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if har != nil {
+		if err := har.Close(); err != nil {
+			return err
+		}
+		if !cfg.Quiet {
+			i18n.Printf("HTTP 请求记录已写入 HAR 文件: %s\n", cfg.HARFile)
+		}
+	}
+	i18n.Printf("URL 扫描完成。总耗时: %v\n", time.Since(startTime))
 	return nil
 }
 
-// readURLsFromFile 从文件中读取 URL 列表
+// processQueuedURL 在 worker 中处理单个 URL：获取并发槽位、发送请求并更新统计
+func processQueuedURL(targetURL string, cfg *config.AppConfig, compiledRules *rules.CompiledRules, client *http.Client, bodies *contentIndex, memory *memoryBudget, out *resultWriter, limiter *concurrencyLimiter, stats *urlScanStats) {
+	// 结果文件已存在时不再请求 (-on-exist skip)，不占用并发额度，也不计入自适应并发的统计
+	if out.skipSource(targetURL) {
+		stats.completed.Add(1)
+		return
+	}
+	limiter.acquire() // 获取信号量
+	outcome := outcomeFailed
+	requestStart := time.Now()
+	stats.inFlight.Add(1)
+	defer func() {
+		limiter.release(outcome, time.Since(requestStart)) // 释放信号量
+		stats.inFlight.Add(-1)
+		stats.completed.Add(1)
+		if outcome != outcomeOK {
+			stats.errors.Add(1)
+		}
+		if outcome == outcomeDNSFailed {
+			stats.dnsErrors.Add(1)
+		}
+	}()
+	outcome = processURL(targetURL, cfg, compiledRules, client, bodies, memory, out)
+}
+
+// readURLsFromFile 从文件中读取 URL 列表 (每行一个)，gzip 压缩的文件会被自动解压
 func readURLsFromFile(filePath string) ([]string, error) {
-	file, err := os.Open(filePath)
+	file, err := utils.OpenInput(filePath) // 支持 gzip 压缩的列表
 	if err != nil {
 		return nil, err
 	}
@@ -110,16 +319,64 @@ func readURLsFromFile(filePath string) ([]string, error) {
 	return urls, scanner.Err()
 }
 
-// processURL 处理单个 URL 的扫描逻辑
-func processURL(targetURL string, cfg *config.AppConfig, compiledRules *rules.CompiledRules, client *http.Client) {
-	originalURL := targetURL // 保存原始 URL 用于日志和输出
+// normalizeTargetURL 为缺少协议的 URL 补全 https:// (defaulted 为 true)，
+// 并为未加方括号的 IPv6 主机加上方括号 (例如 2001:db8::1/app.js -> https://[2001:db8::1]/app.js)
+// 未加方括号的 IPv6 地址无法携带端口，带端口时必须写成 [2001:db8::1]:8080 的形式
+func normalizeTargetURL(raw string) (target string, defaulted bool) {
+	scheme := "https://" // 默认尝试 HTTPS
+	rest := raw
+	if strings.HasPrefix(raw, "http://") || strings.HasPrefix(raw, "https://") {
+		scheme, rest, _ = strings.Cut(raw, "://")
+		scheme += "://"
+	} else {
+		defaulted = true
+	}
+	host, path := rest, ""
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		host, path = rest[:i], rest[i:]
+	}
+	if strings.Contains(host, ":") && net.ParseIP(strings.SplitN(host, "%", 2)[0]) != nil {
+		host = "[" + host + "]"
+	}
+	return scheme + host + path, defaulted
+}
 
-	// 确保 URL 包含协议头
-	if !strings.HasPrefix(targetURL, "http://") && !strings.HasPrefix(targetURL, "https://") {
-		targetURL = "https://" + targetURL // 默认尝试 HTTPS
-		if !cfg.Quiet && cfg.Verbose {
-			fmt.Printf("URL '%s' 缺少协议，默认使用 https://\n", originalURL)
+// readHeadersFile 从文件中读取请求头 (每行一个 "Key: Value")，空行和以 # 开头的注释行被忽略
+// 同名请求头出现多次时全部保留
+func readHeadersFile(filePath string) (http.Header, error) {
+	file, err := utils.OpenInput(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	headers := make(http.Header)
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("第 %d 行格式无效 (应为 \"Key: Value\"): %s", lineNum, line)
 		}
+		headers.Add(key, strings.TrimSpace(value))
+	}
+	return headers, scanner.Err()
+}
+
+// processURL 处理单个 URL 的扫描逻辑，返回请求结果供并发控制使用
+// bodies 用于识别与之前 URL 内容完全相同的响应体，避免重复匹配和重复输出；memory 为 nil 时不限制响应体占用的内存
+func processURL(targetURL string, cfg *config.AppConfig, compiledRules *rules.CompiledRules, client *http.Client, bodies *contentIndex, memory *memoryBudget, out *resultWriter) (outcome urlOutcome) {
+	originalURL := targetURL // 保存原始 URL 用于日志和输出
+	started := time.Now()
+
+	// 确保 URL 包含协议头，并为未加方括号的 IPv6 地址补全方括号
+	targetURL, defaulted := normalizeTargetURL(targetURL)
+	if defaulted && !cfg.Quiet && cfg.Verbose {
+		i18n.Printf("URL '%s' 缺少协议，默认使用 https://\n", originalURL)
 	}
 
 	// --- 创建 HTTP 请求 ---
@@ -130,13 +387,13 @@ func processURL(targetURL string, cfg *config.AppConfig, compiledRules *rules.Co
 
 	req, err := http.NewRequest(cfg.ScanOptions.Method, targetURL, reqBody)
 	if err != nil {
-		fmt.Printf("错误: 创建请求 '%s' 失败: %v\n", originalURL, err)
-		return
+		i18n.Printf("错误: 创建请求 '%s' 失败: %v\n", originalURL, err)
+		return outcomeFailed
 	}
 
 	// --- 设置请求头 ---
 	// 默认 User-Agent
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/108.0.0.0 Safari/537.36")
+	req.Header.Set("User-Agent", defaultUserAgent)
 	// 其他默认头 (根据需要添加或修改)
 	req.Header.Set("Accept", "*/*")
 	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
@@ -147,47 +404,113 @@ func processURL(targetURL string, cfg *config.AppConfig, compiledRules *rules.Co
 
 	// --- 执行请求 ---
 	if !cfg.Quiet && cfg.Verbose {
-		fmt.Printf("正在请求 URL: %s (方法: %s)\n", originalURL, req.Method)
+		i18n.Printf("正在请求 URL: %s (方法: %s)\n", originalURL, req.Method)
 	}
 
-	resp, err := client.Do(req)
+	resp, err := doWithDNSRetry(client, req, cfg)
 	if err != nil {
-		// 尝试 HTTP (如果之前是 HTTPS)
-		if strings.HasPrefix(targetURL, "https://") && strings.Contains(err.Error(), "http: server gave HTTP response to HTTPS client") {
-			targetURL = "http://" + strings.TrimPrefix(targetURL, "https://")
-			if !cfg.Quiet && cfg.Verbose {
-				fmt.Printf("HTTPS 请求失败，尝试 HTTP: %s\n", targetURL)
+		// 根据错误类型决定是否回退到 HTTP (仅当之前是 HTTPS)
+		kind := classifyTLSError(err)
+		if strings.HasPrefix(targetURL, "https://") && kind != tlsErrorNone {
+			// 服务端明确返回了 HTTP 响应时总是回退；其他 TLS/证书错误需显式开启 -allow-http-fallback
+			if kind == tlsErrorSchemeMismatch || cfg.ScanOptions.AllowHTTPFallback {
+				targetURL = "http://" + strings.TrimPrefix(targetURL, "https://")
+				if !cfg.Quiet && cfg.Verbose {
+					i18n.Printf("HTTPS 请求失败 (%s)，尝试 HTTP: %s\n", kind, targetURL)
+				}
+				retryReq := req.Clone(req.Context())
+				retryReq.URL, _ = req.URL.Parse(targetURL) // 更新请求 URL
+				if req.GetBody != nil {
+					retryReq.Body, _ = req.GetBody() // 原请求体已被消费，重新获取
+				}
+				resp, err = doWithDNSRetry(client, retryReq, cfg) // 再次尝试
+			} else {
+				if !cfg.Quiet {
+					i18n.Printf("错误: 请求 URL '%s' 失败 [%s]，已跳过 (可使用 -allow-http-fallback 回退到 HTTP): %v\n", originalURL, kind, err)
+				}
+				return outcomeFailed
 			}
-			req.URL, _ = req.URL.Parse(targetURL) // 更新请求 URL
-			resp, err = client.Do(req)            // 再次尝试
 		}
 
 		if err != nil { // 如果仍然有错误
 			if !cfg.Quiet { // 只有非静默模式才打印 fetch 错误
-				fmt.Printf("错误: 请求 URL '%s' 失败: %v\n", originalURL, err)
+				i18n.Printf("错误: 请求 URL '%s' 失败: %v\n", originalURL, err)
+			}
+			if isTimeout(err) {
+				return outcomeThrottled
 			}
-			return
+			if isDNSError(err) {
+				return outcomeDNSFailed
+			}
+			return outcomeFailed
 		}
 	}
 	defer resp.Body.Close()
 
 	// --- 检查响应状态码 ---
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if !cfg.Quiet && cfg.Verbose { // 只有 verbose 模式才打印非 2xx 状态码
-			fmt.Printf("警告: URL '%s' 返回状态码 %d\n", originalURL, resp.StatusCode)
+	if !cfg.ScanOptions.AcceptsStatus(resp.StatusCode) {
+		statusOutcome := outcomeFailed
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			statusOutcome = outcomeThrottled
 		}
-		// 可以选择性地读取 Body 以获取错误信息，但通常对于扫描目标来说意义不大
-		return
+		if !cfg.ScanOptions.ScansStatus(resp.StatusCode) {
+			if !cfg.Quiet && cfg.Verbose { // 只有 verbose 模式才打印未被接受的状态码
+				i18n.Printf("警告: URL '%s' 返回状态码 %d\n", originalURL, resp.StatusCode)
+			}
+			return statusOutcome
+		}
+		// 错误页 (401/403、自定义错误页等) 中可能包含内部信息，按 -scan-status 仍扫描响应体；
+		// 发现记录该状态码，请求本身仍按失败或限流统计，不影响 -adaptive 降速
+		if !cfg.Quiet && cfg.Verbose {
+			i18n.Printf("URL '%s' 返回状态码 %d，按 -scan-status 扫描响应体。\n", originalURL, resp.StatusCode)
+		}
+		defer func() {
+			if outcome == outcomeOK {
+				outcome = statusOutcome
+			}
+		}()
 	}
 
 	// --- 读取响应体 ---
-	// 限制读取大小防止 OOM
-	maxBodySize := int64(10 * 1024 * 1024) // 10MB 限制
+	// Content-Length 已知且小于 -min-body-size 时不读取响应体
+	minBodySize := cfg.ScanOptions.MinBodySize
+	if resp.ContentLength >= 0 && resp.ContentLength < minBodySize {
+		if !cfg.Quiet && cfg.Verbose {
+			i18n.Printf("URL '%s' 的响应体 (%d 字节) 小于 -min-body-size，已跳过。\n", originalURL, resp.ContentLength)
+		}
+		return outcomeOK
+	}
+
+	// 边下载边匹配 (-stream-body)，发现在下载完成前即写出
+	if cfg.StreamBody {
+		return processURLStreaming(originalURL, req, resp, cfg, compiledRules, memory, out, started)
+	}
+
+	// 限制读取大小防止 OOM (-max-body-size，默认 10MB)
+	maxBodySize := cfg.ScanOptions.MaxBodySize
+
+	// 按 Content-Length (未知时按读取上限) 从 -max-memory 预算中预留额度，额度不足时等待其他 worker 释放
+	reserve := maxBodySize
+	if resp.ContentLength >= 0 {
+		reserve = min(resp.ContentLength, maxBodySize)
+	}
+	reserved, err := memory.acquire(req.Context(), reserve, originalURL)
+	if err != nil {
+		i18n.Printf("错误: 等待 URL '%s' 的内存预算失败: %v\n", originalURL, err)
+		return outcomeFailed
+	}
+	defer func() { memory.release(reserved) }()
+
 	limitedReader := io.LimitReader(resp.Body, maxBodySize)
 	bodyBytes, err := io.ReadAll(limitedReader)
 	if err != nil {
-		fmt.Printf("错误: 读取 URL '%s' 响应体失败: %v\n", originalURL, err)
-		return
+		i18n.Printf("错误: 读取 URL '%s' 响应体失败: %v\n", originalURL, err)
+		return outcomeFailed
+	}
+	// 实际大小小于预留额度 (例如未声明 Content-Length) 时立即归还多余的部分
+	if actual := max(int64(len(bodyBytes)), 1); memory != nil && actual < reserved {
+		memory.release(reserved - actual)
+		reserved = actual
 	}
 
 	// 检查是否读取完整 (如果读取量达到限制，说明可能被截断)
@@ -195,37 +518,179 @@ func processURL(targetURL string, cfg *config.AppConfig, compiledRules *rules.Co
 	oneByte := make([]byte, 1)
 	n, _ := resp.Body.Read(oneByte) // 尝试从原始 Body 读取
 	if n > 0 {
-		fmt.Printf("警告: URL '%s' 的响应体超过 %dMB 限制，只处理了部分内容。\n", originalURL, maxBodySize/(1024*1024))
+		i18n.Printf("警告: URL '%s' 的响应体超过 %d 字节限制，只处理了部分内容。\n", originalURL, maxBodySize)
+	}
+
+	// 未声明 Content-Length (例如分块传输) 时读取后再检查大小
+	if int64(len(bodyBytes)) < minBodySize {
+		if !cfg.Quiet && cfg.Verbose {
+			i18n.Printf("URL '%s' 的响应体 (%d 字节) 小于 -min-body-size，已跳过。\n", originalURL, len(bodyBytes))
+		}
+		return outcomeOK
+	}
+
+	// 部分 CDN 返回的 gzip 数据缺少 Content-Encoding 或带有错误的 Content-Type，按魔数识别并解压
+	if cfg.SniffGzip {
+		decompressed, ok, truncated, err := maybeGunzip(bodyBytes, maxBodySize)
+		if err != nil {
+			if !cfg.Quiet && cfg.Verbose {
+				i18n.Printf("警告: URL '%s' 的响应体看似 gzip 数据但%v，按原始内容扫描。\n", originalURL, err)
+			}
+		} else if ok {
+			bodyBytes = decompressed
+			if truncated {
+				i18n.Printf("警告: URL '%s' 解压后的响应体超过 %d 字节限制，只处理了部分内容。\n", originalURL, maxBodySize)
+			} else if !cfg.Quiet && cfg.Verbose {
+				i18n.Printf("URL '%s' 的响应体为 gzip 数据，已自动解压。\n", originalURL)
+			}
+		}
+	}
+
+	// 按 Content-Type 或 <meta charset> 声明的字符集转换为 UTF-8，使规则能匹配 GBK 等编码的内容
+	if cfg.ScanOptions.Transcode {
+		decoded, charsetName, err := transcodeToUTF8(resp.Header.Get("Content-Type"), bodyBytes)
+		if err != nil {
+			if !cfg.Quiet && cfg.Verbose {
+				i18n.Printf("警告: URL '%s' 字符集转换失败 (%v)，按原始内容扫描。\n", originalURL, err)
+			}
+		} else if charsetName != "" && charsetName != "utf-8" {
+			bodyBytes = decoded
+			if !cfg.Quiet && cfg.Verbose {
+				i18n.Printf("URL '%s' 的响应体已从 %s 转换为 UTF-8。\n", originalURL, charsetName)
+			}
+		}
 	}
 
 	if len(bodyBytes) == 0 {
 		if !cfg.Quiet && cfg.Verbose {
-			fmt.Printf("URL '%s' 响应体为空。\n", originalURL)
+			i18n.Printf("URL '%s' 响应体为空。\n", originalURL)
 		}
-		return
+		return outcomeOK
+	}
+
+	// --- 跳过重复内容 ---
+	if firstSource, duplicate := bodies.markSeen(bodyBytes, originalURL); duplicate {
+		if !cfg.Quiet && cfg.Verbose {
+			if firstSource == "" {
+				i18n.Printf("URL '%s' 的响应体已扫描过 (布隆过滤器判定)，跳过扫描。\n", originalURL)
+			} else {
+				i18n.Printf("URL '%s' 的响应体与 '%s' 相同，跳过扫描。\n", originalURL, firstSource)
+			}
+		}
+		return outcomeOK
 	}
 
 	// --- 处理内容 ---
 	// URL 扫描通常涉及网络 IO，并发正则可能帮助不大，除非响应体特别大
-	results := processContent(originalURL, bodyBytes, compiledRules, false)
+	// 记录响应状态码和重定向后的最终 URL，便于判断匹配来自目标资源还是重定向目标
+	finalURL := resp.Request.URL.String()
+	sink, finish := out.sourceWriter(func(result *ScanResult) {
+		result.Status = resp.StatusCode
+		result.FinalURL = finalURL
+	})
+	results := processContent(originalURL, bodyBytes, compiledRules, cfg, false, sink)
+	out.recordSource(originalURL, len(bodyBytes), len(results), started)
 
 	// --- 写入结果 ---
 	if len(results) > 0 {
-		outputFilePath := GetOutputFilePath(cfg.OutputDir, originalURL)
-		if err := WriteResultsToFile(outputFilePath, results); err != nil {
-			fmt.Printf("错误: 写入结果到 '%s' 失败: %v\n", outputFilePath, err)
+		outputFilePaths, err := finish(results)
+		if err != nil {
+			i18n.Printf("错误: %v\n", err)
 		} else {
-			if !cfg.Quiet {
-				fmt.Printf("发现敏感信息 [%s] -> %s\n", originalURL, outputFilePath)
+			if !cfg.Quiet && len(outputFilePaths) > 0 {
+				i18n.Printf("发现敏感信息 [%s] -> %s\n", originalURL, strings.Join(outputFilePaths, ", "))
 			}
 		}
 	} else if !cfg.Quiet && cfg.Verbose {
-		fmt.Printf("URL '%s' 未发现匹配项。\n", originalURL)
+		i18n.Printf("URL '%s' 未发现匹配项。\n", originalURL)
+	}
+	return outcomeOK
+}
+
+// doWithDNSRetry 发送请求，DNS 解析失败时按 -dns-retries 和 -dns-retry-delay 重试，其他错误不重试
+func doWithDNSRetry(client *http.Client, req *http.Request, cfg *config.AppConfig) (*http.Response, error) {
+	resp, err := client.Do(req)
+	for attempt := 1; err != nil && isDNSError(err) && attempt <= cfg.ScanOptions.DNSRetries; attempt++ {
+		if !cfg.Quiet && cfg.Verbose {
+			i18n.Printf("URL '%s' 的 DNS 解析失败，%v 后进行第 %d 次重试: %v\n", req.URL, cfg.ScanOptions.DNSRetryDelay, attempt, err)
+		}
+		time.Sleep(cfg.ScanOptions.DNSRetryDelay)
+		retryReq := req.Clone(req.Context())
+		if req.GetBody != nil {
+			retryReq.Body, _ = req.GetBody() // 原请求体已被消费，重新获取
+		}
+		resp, err = client.Do(retryReq)
+	}
+	return resp, err
+}
+
+// isDNSError 判断请求错误是否由 DNS 解析失败引起
+func isDNSError(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// isTimeout 判断请求错误是否为超时
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// tlsErrorKind 是 HTTPS 请求失败原因的分类，用于决定是否回退到 HTTP
+type tlsErrorKind int
+
+const (
+	tlsErrorNone           tlsErrorKind = iota
+	tlsErrorSchemeMismatch              // 服务端对 HTTPS 请求返回了 HTTP 响应
+	tlsErrorRecordHeader                // TLS 记录头无效，对端通常不是 TLS 服务
+	tlsErrorCertificate                 // 证书校验失败 (x509)
+)
+
+func (k tlsErrorKind) String() string {
+	switch k {
+	case tlsErrorSchemeMismatch:
+		return "服务端返回 HTTP 响应"
+	case tlsErrorRecordHeader:
+		return "TLS 握手失败"
+	case tlsErrorCertificate:
+		return "证书校验失败"
+	default:
+		return "非 TLS 错误"
+	}
+}
+
+// classifyTLSError 通过错误类型 (而非错误文本) 判断请求错误是否由 TLS 引起
+func classifyTLSError(err error) tlsErrorKind {
+	var (
+		recordErr    tls.RecordHeaderError
+		verifyErr    *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	switch {
+	case errors.Is(err, http.ErrSchemeMismatch):
+		return tlsErrorSchemeMismatch
+	case errors.As(err, &recordErr):
+		return tlsErrorRecordHeader
+	case errors.As(err, &verifyErr), errors.As(err, &authorityErr),
+		errors.As(err, &hostnameErr), errors.As(err, &invalidErr):
+		return tlsErrorCertificate
+	default:
+		return tlsErrorNone
 	}
 }
 
 // applyCustomHeaders 将配置中的 Header, Cookie, Auth 等应用到请求对象
 func applyCustomHeaders(req *http.Request, opts config.ScanOptions) {
+	// 请求头文件 (-headers-file) 和登录流程产生的请求头 (-login)，先于 -H 应用，同名时以 -H 为准
+	for key, values := range opts.FileHeaders {
+		req.Header[key] = slices.Clone(values)
+	}
+	for key, values := range opts.LoginHeaders {
+		req.Header[key] = slices.Clone(values)
+	}
+
 	// 自定义 Header (-H)
 	if opts.Header != "" {
 		// 尝试解析为 JSON
@@ -276,4 +741,9 @@ func applyCustomHeaders(req *http.Request, opts config.ScanOptions) {
 		authEncoded := base64.StdEncoding.EncodeToString([]byte(opts.Auth))
 		req.Header.Set("Authorization", "Basic "+authEncoded)
 	}
+
+	// Host 请求头 (--host-header)，与 URL 中的主机 (例如 IP) 无关，用于访问共享 IP 上的指定虚拟主机
+	if opts.HostHeader != "" {
+		req.Host = opts.HostHeader
+	}
 }
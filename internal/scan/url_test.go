@@ -0,0 +1,198 @@
+package scan
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"jsleaksscan/internal/config"
+	"jsleaksscan/internal/httpclient"
+	"jsleaksscan/internal/rules"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadURLsFromFile(t *testing.T) {
+	const list = "https://example.com/a.js\n\n  https://example.com/b.js  \r\nexample.com/c.js\n"
+	want := []string{"https://example.com/a.js", "https://example.com/b.js", "example.com/c.js"}
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(list))
+	gz.Close()
+
+	dir := t.TempDir()
+	for name, data := range map[string][]byte{"urls.txt": []byte(list), "urls.txt.gz": compressed.Bytes()} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := readURLsFromFile(path)
+		if err != nil {
+			t.Errorf("readURLsFromFile(%s): %v", name, err)
+			continue
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("readURLsFromFile(%s) = %q, 期望 %q", name, got, want)
+		}
+	}
+}
+
+func TestNormalizeTargetURLIPv6(t *testing.T) {
+	tests := []struct {
+		raw           string
+		want          string
+		wantDefaulted bool
+	}{
+		{raw: "http://[::1]:8080/a.js", want: "http://[::1]:8080/a.js"},
+		{raw: "https://[2001:db8::1]/app.js", want: "https://[2001:db8::1]/app.js"},
+		{raw: "[::1]:8080/a.js", want: "https://[::1]:8080/a.js", wantDefaulted: true},
+		{raw: "2001:db8::1/app.js", want: "https://[2001:db8::1]/app.js", wantDefaulted: true},
+		{raw: "::1", want: "https://[::1]", wantDefaulted: true},
+		{raw: "example.com:8080/a.js", want: "https://example.com:8080/a.js", wantDefaulted: true},
+	}
+	for _, tt := range tests {
+		got, defaulted := normalizeTargetURL(tt.raw)
+		if got != tt.want || defaulted != tt.wantDefaulted {
+			t.Errorf("normalizeTargetURL(%q) = (%q, %v), 期望 (%q, %v)", tt.raw, got, defaulted, tt.want, tt.wantDefaulted)
+		}
+	}
+}
+
+// peakSampler 定时采样 goroutine 数和堆内存，记录扫描期间的峰值
+type peakSampler struct {
+	stop       chan struct{}
+	done       chan struct{}
+	goroutines atomic.Int64
+	heapBytes  atomic.Uint64
+}
+
+func startPeakSampler() *peakSampler {
+	s := &peakSampler{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		var mem runtime.MemStats
+		for {
+			if n := int64(runtime.NumGoroutine()); n > s.goroutines.Load() {
+				s.goroutines.Store(n)
+			}
+			runtime.ReadMemStats(&mem)
+			if mem.HeapInuse > s.heapBytes.Load() {
+				s.heapBytes.Store(mem.HeapInuse)
+			}
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return s
+}
+
+func (s *peakSampler) finish() {
+	close(s.stop)
+	<-s.done
+}
+
+// BenchmarkScanURLsMemory 对 httptest 服务器上的大量 URL 比较两种调度方式的内存占用:
+// ScanURLs 使用的固定 worker 池 (min(-t, URL 数) 个 worker 从通道取任务)，以及每个 URL 一个 goroutine、
+// 由信号量限制同时进行的请求数的方式。除 allocs/op 外报告扫描期间的 goroutine 峰值和堆内存峰值
+func BenchmarkScanURLsMemory(b *testing.B) {
+	const urlCount = 5000
+	const concurrency = 50
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "var path = %q;\n", r.URL.Path) // 每个 URL 的响应体不同，不会被去重跳过
+	}))
+	defer server.Close()
+	urls := make([]string, urlCount)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("%s/static/js/chunk-%d.js", server.URL, i)
+	}
+
+	cfg := &config.AppConfig{
+		Mode:        "urlScan",
+		Quiet:       true,
+		OutputDir:   b.TempDir(),
+		MaxMatchLen: 1024,
+		ThreadNum:   concurrency,
+		ScanOptions: config.ScanOptions{Method: http.MethodGet, Timeout: 10, MaxBodySize: 10 * 1024 * 1024},
+	}
+	compiled := compileTestRules(b, `{"aws_key": "AKIA[0-9A-Z]{16}"}`, rules.CompileOptions{})
+	client, err := httpclient.CreateHTTPClient(cfg.ScanOptions)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	strategies := map[string]func(urls []string, process func(string)){
+		"worker-pool": func(urls []string, process func(string)) {
+			queue := make(chan string, concurrency*2)
+			var wg sync.WaitGroup
+			for i := 0; i < min(concurrency, len(urls)); i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for u := range queue {
+						process(u)
+					}
+				}()
+			}
+			for _, u := range urls {
+				queue <- u
+			}
+			close(queue)
+			wg.Wait()
+		},
+		"goroutine-per-URL": func(urls []string, process func(string)) {
+			var wg sync.WaitGroup
+			for _, u := range urls {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					process(u)
+				}()
+			}
+			wg.Wait()
+		},
+	}
+	for _, name := range []string{"worker-pool", "goroutine-per-URL"} {
+		run := strategies[name]
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			var peakGoroutines int64
+			var peakHeap uint64
+			for i := 0; i < b.N; i++ {
+				out, err := newResultWriter(cfg, compiled)
+				if err != nil {
+					b.Fatal(err)
+				}
+				bodies := newContentIndex(nil)
+				limiter := newConcurrencyLimiter(concurrency, false, false)
+				stats := newURLScanStats(len(urls), out.findingCount)
+				runtime.GC()
+				sampler := startPeakSampler()
+				run(urls, func(u string) {
+					processQueuedURL(u, cfg, compiled, client, bodies, nil, out, limiter, stats)
+				})
+				sampler.finish()
+				out.Close()
+				peakGoroutines = max(peakGoroutines, sampler.goroutines.Load())
+				peakHeap = max(peakHeap, sampler.heapBytes.Load())
+				if failed := stats.errors.Load(); failed > 0 {
+					b.Fatalf("%d 个 URL 请求失败", failed)
+				}
+			}
+			b.ReportMetric(float64(peakGoroutines), "peak-goroutines")
+			b.ReportMetric(float64(peakHeap)/(1024*1024), "peak-heap-MB")
+		})
+	}
+}
@@ -1,11 +1,20 @@
 package utils
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
 	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 )
 
 // 缓冲池初始化
@@ -15,13 +24,61 @@ var BufferPool = sync.Pool{
 	},
 }
 
+// gzipReadCloser 关闭 gzip 读取器的同时关闭底层文件
+type gzipReadCloser struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g gzipReadCloser) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+// NewRunID 生成一次扫描运行的 ID，格式为 UTC 开始时间加随机后缀 (例如 20240501T080000Z-3f9a2b1c)，
+// 按字典序排列即为时间顺序
+func NewRunID(start time.Time) string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return start.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+}
+
+// IsRemotePath 判断文件参数是否为 http:// 或 https:// 地址 (例如远程的规则配置或 URL 列表)
+func IsRemotePath(p string) bool {
+	lower := strings.ToLower(p)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// OpenInput 打开输入文件 (URL 列表、规则文件等)，按 gzip 魔数 (1f 8b) 识别压缩文件并透明解压
+// 非 gzip 文件按原样读取，与扩展名无关
+func OpenInput(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(file)
+	magic, _ := reader.Peek(2)
+	if !bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		return struct {
+			io.Reader
+			io.Closer
+		}{reader, file}, nil
+	}
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("解压 gzip 文件 '%s' 失败: %w", path, err)
+	}
+	return gzipReadCloser{Reader: gz, file: file}, nil
+}
+
 // SanitizeFilename 清理文件名，使其安全适用于文件系统
 func SanitizeFilename(path string) string {
 	// 尝试解析为 URL，提取 Hostname 和 Path
 	u, err := url.Parse(path)
 	if err == nil && u.Hostname() != "" { // 确保是有效的 URL 且有 Host
-		// 替换路径中的斜杠为下划线，并结合 Hostname
-		sanitizedPath := u.Hostname() + strings.ReplaceAll(u.Path, "/", "_")
+		// 替换路径中的斜杠为下划线，并结合 Hostname (IPv6 地址的冒号替换为 "-")
+		sanitizedPath := strings.ReplaceAll(u.Hostname(), ":", "-") + strings.ReplaceAll(u.Path, "/", "_")
 		path = sanitizedPath // 使用清理后的路径作为基础
 	} else {
 		// 如果不是 URL 或解析失败，则使用原始路径的基础名
@@ -57,6 +114,20 @@ func SanitizeFilename(path string) string {
 	return sanitized
 }
 
+// HostFilename 将 URL 中的 host[:port] 转换为可用作文件名的形式，端口以 "_" 连接
+// IPv6 地址去掉方括号并将冒号替换为 "-" (例如 [2001:db8::1]:8080 -> 2001-db8--1_8080)
+func HostFilename(hostport string) string {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host, port = strings.TrimSuffix(strings.TrimPrefix(hostport, "["), "]"), ""
+	}
+	name := strings.ReplaceAll(host, ":", "-")
+	if port != "" {
+		name += "_" + port
+	}
+	return name
+}
+
 // ResolveRelativeURL 解析相对URL (如果需要的话)
 func ResolveRelativeURL(base, relative string) string {
 	baseURL, err := url.Parse(base)
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestFile 在临时目录中写入 content，gzipped 为 true 时先用 gzip 压缩，返回文件路径
+func writeTestFile(t *testing.T, name, content string, gzipped bool) string {
+	t.Helper()
+	data := []byte(content)
+	if gzipped {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write(data)
+		gz.Close()
+		data = buf.Bytes()
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpenInput(t *testing.T) {
+	const content = "https://example.com/a.js\nhttps://example.com/b.js\n"
+	tests := []struct {
+		name    string
+		file    string
+		gzipped bool
+	}{
+		{name: "plain", file: "urls.txt"},
+		{name: "gzip", file: "urls.txt.gz", gzipped: true},
+		{name: "gzip without .gz suffix", file: "urls.txt", gzipped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := OpenInput(writeTestFile(t, tt.file, content, tt.gzipped))
+			if err != nil {
+				t.Fatalf("OpenInput: %v", err)
+			}
+			defer file.Close()
+			got, err := io.ReadAll(file)
+			if err != nil {
+				t.Fatalf("读取失败: %v", err)
+			}
+			if string(got) != content {
+				t.Errorf("内容 = %q, 期望 %q", got, content)
+			}
+		})
+	}
+}
+
+func TestOpenInputShortFile(t *testing.T) {
+	// 不足 2 字节、无法判断魔数的文件按原样读取
+	file, err := OpenInput(writeTestFile(t, "short.txt", "x", false))
+	if err != nil {
+		t.Fatalf("OpenInput: %v", err)
+	}
+	defer file.Close()
+	if got, _ := io.ReadAll(file); string(got) != "x" {
+		t.Errorf("内容 = %q, 期望 \"x\"", got)
+	}
+}
+
+func TestOpenInputCorruptGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.gz")
+	if err := os.WriteFile(path, []byte{0x1f, 0x8b, 0x00}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if file, err := OpenInput(path); err == nil {
+		file.Close()
+		t.Fatal("损坏的 gzip 文件应返回错误")
+	}
+}
+
+func TestHostFilename(t *testing.T) {
+	tests := map[string]string{
+		"example.com":       "example.com",
+		"example.com:8080":  "example.com_8080",
+		"[::1]:8080":        "--1_8080",
+		"[::1]":             "--1",
+		"[2001:db8::1]:443": "2001-db8--1_443",
+		"2001:db8::1":       "2001-db8--1",
+		"192.168.1.10:8000": "192.168.1.10_8000",
+	}
+	for hostport, want := range tests {
+		if got := HostFilename(hostport); got != want {
+			t.Errorf("HostFilename(%q) = %q, 期望 %q", hostport, got, want)
+		}
+	}
+}
+
+func TestSanitizeFilenameIPv6(t *testing.T) {
+	tests := map[string]string{
+		"http://[::1]:8080/a.js":              "--1_a.js",
+		"https://[2001:db8::1]/static/app.js": "2001-db8--1_static_app.js",
+		"https://example.com:8443/main.js":    "example.com_main.js",
+	}
+	for source, want := range tests {
+		if got := SanitizeFilename(source); got != want {
+			t.Errorf("SanitizeFilename(%q) = %q, 期望 %q", source, got, want)
+		}
+	}
+}